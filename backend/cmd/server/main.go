@@ -56,14 +56,40 @@ func main() {
 	recipeRepo := repositories.NewRecipeRepository(db)
 	collectionRepo := repositories.NewCollectionRepository(db)
 	shoppingListRepo := repositories.NewShoppingListRepository(db)
+	mealPlanRepo := repositories.NewMealPlanRepository(db)
+	importJobRepo := repositories.NewImportJobRepository(db)
+	revisionRepo := repositories.NewRecipeRevisionRepository(db)
+	shareLinkRepo := repositories.NewShareLinkRepository(db)
+	trashRepo := repositories.NewTrashRepository(db)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.AccessExpiry, cfg.JWT.RefreshExpiry)
 	userService := services.NewUserService(userRepo)
-	recipeService := services.NewRecipeService(recipeRepo)
-	collectionService := services.NewCollectionService(collectionRepo)
-	shoppingListService := services.NewShoppingListService(shoppingListRepo)
+	recipeService := services.NewRecipeService(recipeRepo, userRepo, revisionRepo, cfg.Server.AppURL)
+	collectionService := services.NewCollectionService(collectionRepo, recipeService)
+	shoppingListService := services.NewShoppingListService(shoppingListRepo, recipeRepo)
+	mealPlanService := services.NewMealPlanService(mealPlanRepo, recipeRepo, shoppingListRepo, userRepo, cfg.Server.AppURL, cfg.Server.APIURL)
 	uploadService := services.NewUploadService(cfg)
+	shareLinkService := services.NewShareLinkService(shareLinkRepo, recipeRepo, collectionRepo, recipeService, cfg.Server.APIURL)
+	trashService := services.NewTrashService(trashRepo, recipeRepo, cfg.Trash.Retention)
+
+	importService := services.NewImportService(importJobRepo, recipeRepo, recipeService, uploadService, cfg.Upload.MaxArchiveSize)
+
+	// Imports still running when the server stopped will never finish
+	if err := importService.FailInterruptedJobs(); err != nil {
+		log.Println("Warning: Failed to mark interrupted import jobs as failed:", err)
+	}
+
+	// Derive dietary labels for recipes saved before they existed
+	if err := recipeService.BackfillDietaryLabels(); err != nil {
+		log.Println("Warning: Failed to backfill dietary labels:", err)
+	}
+
+	// Publish scheduled drafts as they fall due
+	recipeService.StartScheduledPublishing()
+
+	// Purge trash once its retention period is over
+	trashService.StartRetention()
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
@@ -71,7 +97,11 @@ func main() {
 	recipeHandler := handlers.NewRecipeHandler(recipeService)
 	collectionHandler := handlers.NewCollectionHandler(collectionService)
 	shoppingListHandler := handlers.NewShoppingListHandler(shoppingListService)
+	mealPlanHandler := handlers.NewMealPlanHandler(mealPlanService)
 	uploadHandler := handlers.NewUploadHandler(uploadService)
+	importHandler := handlers.NewImportHandler(importService, cfg.Upload.MaxArchiveSize)
+	shareLinkHandler := handlers.NewShareLinkHandler(shareLinkService)
+	trashHandler := handlers.NewTrashHandler(trashService)
 
 	// Setup Gin router
 	if cfg.Server.Mode == "release" {
@@ -133,6 +163,9 @@ func main() {
 			users.PUT("/profile", userHandler.UpdateProfile)
 			users.DELETE("/profile", userHandler.DeleteProfile)
 			users.POST("/change-password", userHandler.ChangePassword)
+			users.GET("/staples", userHandler.GetStaples)
+			users.POST("/staples", userHandler.AddStaple)
+			users.DELETE("/staples/:id", userHandler.DeleteStaple)
 		}
 
 		// Recipe routes
@@ -140,10 +173,21 @@ func main() {
 		{
 			// Public routes
 			recipes.GET("", recipeHandler.GetRecipes)
-			recipes.GET("/:id", recipeHandler.GetRecipe)
+			recipes.GET("/:id/forks", recipeHandler.GetRecipeForks)
 			recipes.GET("/search", recipeHandler.SearchRecipes)
+			recipes.GET("/autocomplete", recipeHandler.Autocomplete)
 			recipes.GET("/featured", recipeHandler.GetFeaturedRecipes)
 
+			// Public routes that also serve the caller's own unlisted recipes
+			viewable := recipes.Group("")
+			viewable.Use(middleware.OptionalAuthMiddleware(cfg.JWT.Secret))
+			{
+				viewable.GET("/:id", recipeHandler.GetRecipe)
+				viewable.GET("/:id/export", recipeHandler.ExportRecipe)
+				viewable.GET("/:id/print", recipeHandler.PrintRecipe)
+				viewable.GET("/:id/cook-mode", recipeHandler.GetCookMode)
+			}
+
 			// Authenticated routes
 			authenticated := recipes.Group("")
 			authenticated.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
@@ -154,11 +198,29 @@ func main() {
 				authenticated.POST("/:id/favorite", recipeHandler.FavoriteRecipe)
 				authenticated.DELETE("/:id/favorite", recipeHandler.UnfavoriteRecipe)
 				authenticated.POST("/:id/rate", recipeHandler.RateRecipe)
+				authenticated.POST("/:id/fork", recipeHandler.ForkRecipe)
+				authenticated.POST("/:id/share-links", shareLinkHandler.CreateRecipeShareLink)
+				authenticated.GET("/:id/share-links", shareLinkHandler.GetRecipeShareLinks)
+				authenticated.GET("/:id/revisions", recipeHandler.GetRecipeRevisions)
+				authenticated.GET("/:id/revisions/:rev", recipeHandler.GetRecipeRevision)
+				authenticated.GET("/:id/revisions/:rev/diff", recipeHandler.DiffRecipeRevision)
+				authenticated.POST("/:id/revisions/:rev/restore", recipeHandler.RestoreRecipeRevision)
 				authenticated.GET("/my-recipes", recipeHandler.GetMyRecipes)
 				authenticated.GET("/favorites", recipeHandler.GetFavorites)
+				authenticated.POST("/by-ingredients", recipeHandler.FindByIngredients)
+				authenticated.POST("/import", recipeHandler.ImportRecipe)
+				authenticated.POST("/import/archive", importHandler.ImportArchive)
+				authenticated.GET("/import/jobs", importHandler.GetImportJobs)
+				authenticated.GET("/import/jobs/:id", importHandler.GetImportJob)
 			}
 		}
 
+		// Ingredient routes
+		ingredients := v1.Group("/ingredients")
+		{
+			ingredients.POST("/parse", recipeHandler.ParseIngredients)
+		}
+
 		// Collection routes (authenticated)
 		collections := v1.Group("/collections")
 		collections.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
@@ -170,20 +232,70 @@ func main() {
 			collections.DELETE("/:id", collectionHandler.DeleteCollection)
 			collections.POST("/:id/recipes", collectionHandler.AddRecipeToCollection)
 			collections.DELETE("/:id/recipes/:recipeId", collectionHandler.RemoveRecipeFromCollection)
+			collections.GET("/:id/print", collectionHandler.PrintCollection)
+			collections.POST("/:id/share-links", shareLinkHandler.CreateCollectionShareLink)
+			collections.GET("/:id/share-links", shareLinkHandler.GetCollectionShareLinks)
+		}
+
+		// Share link routes (authenticated)
+		shareLinks := v1.Group("/share-links")
+		shareLinks.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		{
+			shareLinks.GET("", shareLinkHandler.GetShareLinks)
+			shareLinks.DELETE("/:id", shareLinkHandler.RevokeShareLink)
 		}
 
+		// Trash routes (authenticated)
+		trash := v1.Group("/trash")
+		trash.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		{
+			trash.GET("", trashHandler.GetTrash)
+			trash.DELETE("", trashHandler.EmptyTrash)
+			trash.POST("/:type/:id/restore", trashHandler.RestoreItem)
+			trash.DELETE("/:type/:id", trashHandler.PurgeItem)
+		}
+
+		// Shared items (public, authenticated by share link token)
+		v1.GET("/shared/:token", shareLinkHandler.GetSharedItem)
+
 		// Shopping list routes (authenticated)
 		shoppingLists := v1.Group("/shopping-lists")
 		shoppingLists.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
 		{
 			shoppingLists.GET("", shoppingListHandler.GetShoppingLists)
 			shoppingLists.POST("", shoppingListHandler.CreateShoppingList)
+			shoppingLists.POST("/from-recipes", shoppingListHandler.CreateFromRecipes)
 			shoppingLists.GET("/:id", shoppingListHandler.GetShoppingList)
 			shoppingLists.PUT("/:id", shoppingListHandler.UpdateShoppingList)
 			shoppingLists.DELETE("/:id", shoppingListHandler.DeleteShoppingList)
 			shoppingLists.POST("/:id/items", shoppingListHandler.AddItem)
 			shoppingLists.PUT("/:id/items/:itemId", shoppingListHandler.UpdateItem)
 			shoppingLists.DELETE("/:id/items/:itemId", shoppingListHandler.DeleteItem)
+			shoppingLists.POST("/:id/recipes", shoppingListHandler.AddRecipe)
+			shoppingLists.DELETE("/:id/recipes/:recipeId", shoppingListHandler.RemoveRecipe)
+		}
+
+		// Meal plan routes
+		mealPlans := v1.Group("/meal-plans")
+		{
+			// Calendar feed (authenticated by feed token)
+			mealPlans.GET("/feed.ics", mealPlanHandler.GetFeed)
+
+			// Authenticated routes
+			authenticated := mealPlans.Group("")
+			authenticated.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+			{
+				authenticated.GET("", mealPlanHandler.GetMealPlan)
+				authenticated.POST("/entries", mealPlanHandler.CreateEntry)
+				authenticated.PUT("/entries/:id", mealPlanHandler.UpdateEntry)
+				authenticated.DELETE("/entries/:id", mealPlanHandler.DeleteEntry)
+				authenticated.POST("/entries/:id/move", mealPlanHandler.MoveEntry)
+				authenticated.POST("/entries/:id/copy", mealPlanHandler.CopyEntry)
+				authenticated.POST("/repeat-week", mealPlanHandler.RepeatWeek)
+				authenticated.POST("/shopping-list", mealPlanHandler.GenerateShoppingList)
+				authenticated.POST("/feed-token", mealPlanHandler.CreateFeedToken)
+				authenticated.DELETE("/feed-token", mealPlanHandler.RevokeFeedToken)
+			}
 		}
 	}
 
@@ -21,12 +21,15 @@ type Config struct {
 	RateLimit RateLimitConfig
 	CORS      CORSConfig
 	Upload    UploadConfig
+	Trash     TrashConfig
 }
 
 type ServerConfig struct {
-	Host string
-	Port string
-	Mode string
+	Host   string
+	Port   string
+	Mode   string
+	AppURL string // the web app, for links to recipes
+	APIURL string // this server as reached by clients, for feed and share links
 }
 
 type DatabaseConfig struct {
@@ -84,20 +87,29 @@ type CORSConfig struct {
 
 type UploadConfig struct {
 	MaxSize           int64
+	MaxArchiveSize    int64 // recipe archives imported from other apps
 	AllowedImageTypes []string
 }
 
+type TrashConfig struct {
+	Retention time.Duration // how long deleted items can be restored; 0 keeps them forever
+}
+
 func Load() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	port := getEnv("PORT", "8080")
+
 	return &Config{
 		Server: ServerConfig{
-			Host: getEnv("HOST", "0.0.0.0"),
-			Port: getEnv("PORT", "8080"),
-			Mode: getEnv("GIN_MODE", "debug"),
+			Host:   getEnv("HOST", "0.0.0.0"),
+			Port:   port,
+			Mode:   getEnv("GIN_MODE", "debug"),
+			AppURL: getEnv("APP_URL", "http://localhost:8081"),
+			APIURL: getEnv("API_URL", "http://localhost:"+port),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -146,8 +158,12 @@ func Load() *Config {
 		},
 		Upload: UploadConfig{
 			MaxSize:           parseSize(getEnv("MAX_UPLOAD_SIZE", "10MB")),
+			MaxArchiveSize:    parseSize(getEnv("MAX_ARCHIVE_SIZE", "100MB")),
 			AllowedImageTypes: strings.Split(getEnv("ALLOWED_IMAGE_TYPES", "jpg,jpeg,png,webp"), ","),
 		},
+		Trash: TrashConfig{
+			Retention: time.Duration(parseInt(getEnv("TRASH_RETENTION_DAYS", "30"))) * 24 * time.Hour,
+		},
 	}
 }
 
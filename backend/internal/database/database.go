@@ -58,6 +58,11 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to create uuid extension: %w", err)
 	}
 
+	// Enable trigram extension for typo-tolerant search
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return fmt.Errorf("failed to create pg_trgm extension: %w", err)
+	}
+
 	// Auto-migrate all models
 	err := db.AutoMigrate(
 		&models.User{},
@@ -70,11 +75,23 @@ func Migrate(db *gorm.DB) error {
 		&models.Collection{},
 		&models.ShoppingList{},
 		&models.ShoppingListItem{},
+		&models.ShoppingListItemSource{},
+		&models.MealPlanEntry{},
+		&models.MealPlanFeedToken{},
+		&models.PantryStaple{},
+		&models.ImportJob{},
+		&models.RecipeRevision{},
+		&models.ShareLink{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// Maintain the weighted full-text search vector on recipes
+	if err := createSearchVector(db); err != nil {
+		return fmt.Errorf("failed to create search vector: %w", err)
+	}
+
 	// Create indexes for better performance
 	if err := createIndexes(db); err != nil {
 		return fmt.Errorf("failed to create indexes: %w", err)
@@ -91,8 +108,11 @@ func createIndexes(db *gorm.DB) error {
 		"CREATE INDEX IF NOT EXISTS idx_recipes_difficulty ON recipes(difficulty)",
 		"CREATE INDEX IF NOT EXISTS idx_recipes_rating ON recipes(rating)",
 		"CREATE INDEX IF NOT EXISTS idx_recipes_created_at ON recipes(created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_recipes_title_gin ON recipes USING gin(to_tsvector('english', title))",
-		"CREATE INDEX IF NOT EXISTS idx_recipes_description_gin ON recipes USING gin(to_tsvector('english', description))",
+		"CREATE INDEX IF NOT EXISTS idx_recipes_search_vector_gin ON recipes USING gin(search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_recipes_dietary_labels_gin ON recipes USING gin(dietary_labels)",
+		"CREATE INDEX IF NOT EXISTS idx_recipes_title_trgm ON recipes USING gin(lower(title) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_ingredients_name_trgm ON ingredients USING gin(lower(name) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_tags_name_trgm ON tags USING gin(lower(name) gin_trgm_ops)",
 		"CREATE INDEX IF NOT EXISTS idx_ingredients_recipe_id ON ingredients(recipe_id)",
 		"CREATE INDEX IF NOT EXISTS idx_instructions_recipe_id ON instructions(recipe_id)",
 		"CREATE INDEX IF NOT EXISTS idx_ratings_recipe_id ON ratings(recipe_id)",
@@ -100,6 +120,9 @@ func createIndexes(db *gorm.DB) error {
 		"CREATE INDEX IF NOT EXISTS idx_collections_user_id ON collections(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_shopping_lists_user_id ON shopping_lists(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_shopping_list_items_list_id ON shopping_list_items(shopping_list_id)",
+		"CREATE INDEX IF NOT EXISTS idx_shopping_list_item_sources_item_id ON shopping_list_item_sources(shopping_list_item_id)",
+		"CREATE INDEX IF NOT EXISTS idx_shopping_list_item_sources_recipe_id ON shopping_list_item_sources(recipe_id)",
+		"CREATE INDEX IF NOT EXISTS idx_meal_plan_entries_user_date ON meal_plan_entries(user_id, date)",
 	}
 
 	for _, index := range indexes {
@@ -109,4 +132,61 @@ func createIndexes(db *gorm.DB) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// createSearchVector adds the recipes.search_vector column and the triggers
+// that keep it up to date. Titles weigh most, then descriptions, then tag and
+// ingredient names.
+func createSearchVector(db *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE recipes ADD COLUMN IF NOT EXISTS search_vector tsvector",
+		// Superseded by idx_recipes_search_vector_gin
+		"DROP INDEX IF EXISTS idx_recipes_title_gin",
+		"DROP INDEX IF EXISTS idx_recipes_description_gin",
+		`CREATE OR REPLACE FUNCTION recipe_search_vector(uuid, text, text) RETURNS tsvector AS $$
+			SELECT setweight(to_tsvector('english', coalesce($2, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce($3, '')), 'B') ||
+				setweight(to_tsvector('english', coalesce((
+					SELECT string_agg(t.name, ' ') FROM recipe_tags rt JOIN tags t ON t.id = rt.tag_id WHERE rt.recipe_id = $1
+				), '')), 'C') ||
+				setweight(to_tsvector('english', coalesce((
+					SELECT string_agg(i.name, ' ') FROM ingredients i WHERE i.recipe_id = $1
+				), '')), 'C')
+		$$ LANGUAGE sql STABLE`,
+		`CREATE OR REPLACE FUNCTION recipes_search_vector_trigger() RETURNS trigger AS $$
+		BEGIN
+			NEW.search_vector := recipe_search_vector(NEW.id, NEW.title, NEW.description);
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`,
+		`CREATE OR REPLACE FUNCTION recipe_children_search_vector_trigger() RETURNS trigger AS $$
+		DECLARE
+			changed_recipe_id uuid;
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				changed_recipe_id := OLD.recipe_id;
+			ELSE
+				changed_recipe_id := NEW.recipe_id;
+			END IF;
+			UPDATE recipes SET search_vector = recipe_search_vector(id, title, description) WHERE id = changed_recipe_id;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS recipes_search_vector_update ON recipes",
+		"CREATE TRIGGER recipes_search_vector_update BEFORE INSERT OR UPDATE OF title, description ON recipes FOR EACH ROW EXECUTE PROCEDURE recipes_search_vector_trigger()",
+		"DROP TRIGGER IF EXISTS ingredients_search_vector_update ON ingredients",
+		"CREATE TRIGGER ingredients_search_vector_update AFTER INSERT OR UPDATE OR DELETE ON ingredients FOR EACH ROW EXECUTE PROCEDURE recipe_children_search_vector_trigger()",
+		"DROP TRIGGER IF EXISTS recipe_tags_search_vector_update ON recipe_tags",
+		"CREATE TRIGGER recipe_tags_search_vector_update AFTER INSERT OR DELETE ON recipe_tags FOR EACH ROW EXECUTE PROCEDURE recipe_children_search_vector_trigger()",
+		// Backfill recipes created before the column existed
+		"UPDATE recipes SET search_vector = recipe_search_vector(id, title, description) WHERE search_vector IS NULL",
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/models"
+)
+
+// cooklangSpecial strips characters that have a meaning in Cooklang from
+// ingredient names and notes.
+var cooklangSpecial = strings.NewReplacer("@", "", "#", "", "~", "", "{", "", "}", "", "%", "", "(", "", ")", "")
+
+// renderCooklang writes a recipe in Cooklang (https://cooklang.org), where
+// ingredients are marked up inside the steps that use them. Each ingredient
+// is tagged where a step first mentions it. Ingredients no step mentions
+// are gathered in a first step so none are lost.
+func renderCooklang(recipe *models.Recipe) string {
+	var b strings.Builder
+
+	meta := func(key, value string) {
+		if value = strings.TrimSpace(strings.ReplaceAll(value, "\n", " ")); value != "" {
+			fmt.Fprintf(&b, ">> %s: %s\n", key, value)
+		}
+	}
+	meta("title", recipe.Title)
+	if recipe.Description != nil {
+		meta("description", *recipe.Description)
+	}
+	if recipe.Servings != nil {
+		meta("servings", strconv.Itoa(*recipe.Servings))
+	}
+	if recipe.PrepTime != nil {
+		meta("prep time", fmt.Sprintf("%d minutes", *recipe.PrepTime))
+	}
+	if recipe.CookTime != nil {
+		meta("cook time", fmt.Sprintf("%d minutes", *recipe.CookTime))
+	}
+	if recipe.Difficulty != nil {
+		meta("difficulty", *recipe.Difficulty)
+	}
+	if recipe.Type != nil {
+		meta("course", *recipe.Type)
+	}
+	meta("tags", strings.Join(tagNames(recipe), ", "))
+	if recipe.SourceURL != nil {
+		meta("source", *recipe.SourceURL)
+	}
+	if recipe.ImageURL != nil {
+		meta("image", *recipe.ImageURL)
+	}
+
+	if list := nutrients(recipe.Nutrition); len(list) > 0 {
+		parts := make([]string, len(list))
+		for i, n := range list {
+			parts[i] = strings.ToLower(n.label) + " " + n.String()
+		}
+		fmt.Fprintf(&b, "\n-- Nutrition per serving: %s\n", strings.Join(parts, ", "))
+	}
+
+	instructions := sortedInstructions(recipe)
+	steps := make([]string, len(instructions))
+	for i, instruction := range instructions {
+		steps[i] = strings.Join(strings.Fields(instruction.Instruction), " ")
+	}
+
+	// Tags are swapped in for placeholders at the end, so a later
+	// ingredient such as "salt" can't match inside "@sea salt{}"
+	var tags []string
+	var unused []string
+	for _, ingredient := range sortedIngredients(recipe) {
+		tag := cooklangIngredient(ingredient)
+		placeholder := fmt.Sprintf("\x00%d\x00", len(tags))
+		tags = append(tags, tag)
+
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(ingredient.Name) + `(e?s)?\b`)
+		placed := false
+		for i, step := range steps {
+			if loc := pattern.FindStringIndex(step); loc != nil {
+				steps[i] = step[:loc[0]] + placeholder + step[loc[1]:]
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			unused = append(unused, placeholder)
+		}
+	}
+	if len(unused) > 0 {
+		steps = append([]string{"Gather " + joinList(unused) + "."}, steps...)
+	}
+
+	for i, instruction := range instructions {
+		if instruction.TimerMinutes != nil {
+			index := i
+			if len(unused) > 0 {
+				index++
+			}
+			steps[index] += fmt.Sprintf(" (~{%d%%minutes})", *instruction.TimerMinutes)
+		}
+	}
+
+	for _, step := range steps {
+		for i, tag := range tags {
+			step = strings.Replace(step, fmt.Sprintf("\x00%d\x00", i), tag, 1)
+		}
+		fmt.Fprintf(&b, "\n%s\n", step)
+	}
+
+	return b.String()
+}
+
+// cooklangIngredient writes an ingredient as "@name{amount%unit}(notes)".
+func cooklangIngredient(ingredient models.Ingredient) string {
+	quantity := ""
+	if ingredient.Amount != nil {
+		quantity = strconv.FormatFloat(*ingredient.Amount, 'f', -1, 64)
+		if ingredient.Unit != nil && *ingredient.Unit != "" {
+			quantity += "%" + cooklangSpecial.Replace(*ingredient.Unit)
+		}
+	}
+
+	tag := "@" + strings.TrimSpace(cooklangSpecial.Replace(ingredient.Name)) + "{" + quantity + "}"
+	if ingredient.Notes != nil && *ingredient.Notes != "" {
+		tag += "(" + cooklangSpecial.Replace(*ingredient.Notes) + ")"
+	}
+	return tag
+}
+
+// joinList joins items as "a, b and c".
+func joinList(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+	"yummio-backend/internal/models"
+)
+
+// renderDocument writes a recipe as Markdown or, without markup, as plain
+// text laid out the same way.
+func renderDocument(recipe *models.Recipe, markdown bool) string {
+	var b strings.Builder
+
+	heading := func(title string) {
+		if markdown {
+			fmt.Fprintf(&b, "## %s\n\n", title)
+		} else {
+			fmt.Fprintf(&b, "%s\n%s\n\n", strings.ToUpper(title), strings.Repeat("-", len(title)))
+		}
+	}
+
+	if markdown {
+		fmt.Fprintf(&b, "# %s\n\n", recipe.Title)
+	} else {
+		fmt.Fprintf(&b, "%s\n%s\n\n", recipe.Title, strings.Repeat("=", len([]rune(recipe.Title))))
+	}
+
+	if recipe.Description != nil && *recipe.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", *recipe.Description)
+	}
+	if markdown && recipe.ImageURL != nil && *recipe.ImageURL != "" {
+		fmt.Fprintf(&b, "![%s](%s)\n\n", recipe.Title, *recipe.ImageURL)
+	}
+
+	var details [][2]string
+	if recipe.PrepTime != nil {
+		details = append(details, [2]string{"Prep time", minutesText(*recipe.PrepTime)})
+	}
+	if recipe.CookTime != nil {
+		details = append(details, [2]string{"Cook time", minutesText(*recipe.CookTime)})
+	}
+	if recipe.Servings != nil {
+		details = append(details, [2]string{"Servings", fmt.Sprint(*recipe.Servings)})
+	}
+	if recipe.Difficulty != nil {
+		details = append(details, [2]string{"Difficulty", *recipe.Difficulty})
+	}
+	if recipe.Type != nil {
+		details = append(details, [2]string{"Course", *recipe.Type})
+	}
+	if recipe.SourceURL != nil {
+		details = append(details, [2]string{"Source", *recipe.SourceURL})
+	}
+	if tags := tagNames(recipe); len(tags) > 0 {
+		details = append(details, [2]string{"Tags", strings.Join(tags, ", ")})
+	}
+	for _, detail := range details {
+		if markdown {
+			fmt.Fprintf(&b, "- **%s:** %s\n", detail[0], detail[1])
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", detail[0], detail[1])
+		}
+	}
+	if len(details) > 0 {
+		b.WriteString("\n")
+	}
+
+	if ingredients := sortedIngredients(recipe); len(ingredients) > 0 {
+		heading("Ingredients")
+		for _, ingredient := range ingredients {
+			fmt.Fprintf(&b, "- %s\n", ingredientLine(ingredient))
+		}
+		b.WriteString("\n")
+	}
+
+	if instructions := sortedInstructions(recipe); len(instructions) > 0 {
+		heading("Instructions")
+		for i, instruction := range instructions {
+			fmt.Fprintf(&b, "%d. %s", i+1, instruction.Instruction)
+			if instruction.TimerMinutes != nil {
+				timer := "Timer: " + minutesText(*instruction.TimerMinutes)
+				if markdown {
+					timer = "_" + timer + "_"
+				}
+				fmt.Fprintf(&b, " (%s)", timer)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if list := nutrients(recipe.Nutrition); len(list) > 0 {
+		heading("Nutrition per serving")
+		for _, n := range list {
+			fmt.Fprintf(&b, "- %s: %s\n", n.label, n)
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
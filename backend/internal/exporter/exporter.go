@@ -0,0 +1,222 @@
+// Package exporter renders recipes as Markdown, plain text, schema.org
+// JSON-LD and Cooklang, so they can be shared outside the app. The output is
+// written to be readable by the importer package where the format allows.
+// Recipes and collections are also laid out for printing as HTML and PDF.
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/units"
+)
+
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	Text     Format = "text"
+	JSONLD   Format = "jsonld"
+	Cooklang Format = "cooklang"
+)
+
+// ParseFormat validates an export format name as sent by clients.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case Markdown, "md":
+		return Markdown, nil
+	case Text, "txt":
+		return Text, nil
+	case JSONLD, "json-ld":
+		return JSONLD, nil
+	case Cooklang, "cook":
+		return Cooklang, nil
+	}
+	return "", errors.New("format must be markdown, text, jsonld or cooklang")
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case JSONLD:
+		return "application/ld+json; charset=utf-8"
+	case HTML:
+		return "text/html; charset=utf-8"
+	case PDF:
+		return "application/pdf"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Extension returns the usual file extension of the format.
+func (f Format) Extension() string {
+	switch f {
+	case Markdown:
+		return "md"
+	case JSONLD:
+		return "jsonld"
+	case Cooklang:
+		return "cook"
+	case HTML:
+		return "html"
+	case PDF:
+		return "pdf"
+	default:
+		return "txt"
+	}
+}
+
+// Export renders a recipe in the given format. recipeURL is the recipe's
+// page in the app, used by formats that link back to it.
+func Export(recipe *models.Recipe, format Format, recipeURL string) ([]byte, error) {
+	switch format {
+	case Markdown:
+		return []byte(renderDocument(recipe, true)), nil
+	case Text:
+		return []byte(renderDocument(recipe, false)), nil
+	case JSONLD:
+		return renderJSONLD(recipe, recipeURL)
+	case Cooklang:
+		return []byte(renderCooklang(recipe)), nil
+	}
+	return nil, errors.New("unsupported export format")
+}
+
+// sortedIngredients returns the ingredients in recipe order.
+func sortedIngredients(recipe *models.Recipe) []models.Ingredient {
+	ingredients := append([]models.Ingredient(nil), recipe.Ingredients...)
+	sort.SliceStable(ingredients, func(i, j int) bool {
+		return ingredients[i].OrderIndex < ingredients[j].OrderIndex
+	})
+	return ingredients
+}
+
+// sortedInstructions returns the instructions in step order.
+func sortedInstructions(recipe *models.Recipe) []models.Instruction {
+	instructions := append([]models.Instruction(nil), recipe.Instructions...)
+	sort.SliceStable(instructions, func(i, j int) bool {
+		return instructions[i].Step < instructions[j].Step
+	})
+	return instructions
+}
+
+// ingredientLine writes an ingredient as a recipe line, such as
+// "1 1/2 cups all-purpose flour, sifted", which the ingredient parser reads
+// back into the same fields.
+func ingredientLine(ingredient models.Ingredient) string {
+	var parts []string
+	if ingredient.Amount != nil {
+		parts = append(parts, units.FormatAmount(*ingredient.Amount))
+	}
+	if ingredient.Unit != nil && *ingredient.Unit != "" {
+		amount := 1.0
+		if ingredient.Amount != nil {
+			amount = *ingredient.Amount
+		}
+		parts = append(parts, unitLabel(amount, *ingredient.Unit))
+	}
+	parts = append(parts, ingredient.Name)
+
+	line := strings.Join(parts, " ")
+	if ingredient.Notes != nil && *ingredient.Notes != "" {
+		line += ", " + *ingredient.Notes
+	}
+	return line
+}
+
+// unitLabel pluralises spelled-out units for amounts above one. Abbreviated
+// units such as "tbsp" or "g" stay as they are.
+func unitLabel(amount float64, unit string) string {
+	if amount <= 1 || len(unit) <= 2 || strings.ContainsAny(unit, " .") {
+		return unit
+	}
+	switch unit {
+	case "tsp", "tbsp", "oz", "lb", "kg", "ml":
+		return unit
+	}
+	if strings.HasSuffix(unit, "ch") || strings.HasSuffix(unit, "sh") {
+		return unit + "es"
+	}
+	if strings.HasSuffix(unit, "s") {
+		return unit
+	}
+	return unit + "s"
+}
+
+// minutesText writes a duration such as "1 h 15 min".
+func minutesText(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+// isoDuration writes minutes as an ISO 8601 duration such as "PT1H15M".
+func isoDuration(minutes int) string {
+	if minutes >= 60 {
+		if minutes%60 == 0 {
+			return fmt.Sprintf("PT%dH", minutes/60)
+		}
+		return fmt.Sprintf("PT%dH%dM", minutes/60, minutes%60)
+	}
+	return fmt.Sprintf("PT%dM", minutes)
+}
+
+// nutrient is a nutrition value with its label and unit.
+type nutrient struct {
+	label string
+	value float64
+	unit  string
+}
+
+// nutrients lists the recipe's per-serving nutrition values that are set.
+func nutrients(n *models.Nutrition) []nutrient {
+	if n == nil {
+		return nil
+	}
+
+	var list []nutrient
+	if n.Calories != nil {
+		list = append(list, nutrient{"Calories", float64(*n.Calories), "kcal"})
+	}
+	for _, v := range []struct {
+		label string
+		value *float64
+		unit  string
+	}{
+		{"Protein", n.Protein, "g"},
+		{"Carbohydrates", n.Carbs, "g"},
+		{"Fat", n.Fat, "g"},
+		{"Fiber", n.Fiber, "g"},
+		{"Sugar", n.Sugar, "g"},
+		{"Sodium", n.Sodium, "mg"},
+		{"Cholesterol", n.Cholesterol, "mg"},
+	} {
+		if v.value != nil {
+			list = append(list, nutrient{v.label, *v.value, v.unit})
+		}
+	}
+	return list
+}
+
+func (n nutrient) String() string {
+	return strconv.FormatFloat(math.Round(n.value*10)/10, 'f', -1, 64) + " " + n.unit
+}
+
+func tagNames(recipe *models.Recipe) []string {
+	names := make([]string, 0, len(recipe.Tags))
+	for _, tag := range recipe.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
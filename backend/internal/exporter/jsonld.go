@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"yummio-backend/internal/models"
+)
+
+// schema.org types for the JSON-LD export. Structs keep the properties in
+// a readable order.
+type jsonLDRecipe struct {
+	Context            string                 `json:"@context"`
+	Type               string                 `json:"@type"`
+	Name               string                 `json:"name"`
+	Description        string                 `json:"description,omitempty"`
+	Image              string                 `json:"image,omitempty"`
+	URL                string                 `json:"url,omitempty"`
+	IsBasedOn          string                 `json:"isBasedOn,omitempty"`
+	Author             *jsonLDPerson          `json:"author,omitempty"`
+	DatePublished      string                 `json:"datePublished,omitempty"`
+	PrepTime           string                 `json:"prepTime,omitempty"`
+	CookTime           string                 `json:"cookTime,omitempty"`
+	TotalTime          string                 `json:"totalTime,omitempty"`
+	RecipeYield        string                 `json:"recipeYield,omitempty"`
+	RecipeCategory     string                 `json:"recipeCategory,omitempty"`
+	Keywords           string                 `json:"keywords,omitempty"`
+	RecipeIngredient   []string               `json:"recipeIngredient"`
+	RecipeInstructions []jsonLDStep           `json:"recipeInstructions"`
+	Nutrition          *jsonLDNutrition       `json:"nutrition,omitempty"`
+	AggregateRating    *jsonLDAggregateRating `json:"aggregateRating,omitempty"`
+}
+
+type jsonLDPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type jsonLDStep struct {
+	Type         string `json:"@type"`
+	Position     int    `json:"position"`
+	Text         string `json:"text"`
+	Image        string `json:"image,omitempty"`
+	TimeRequired string `json:"timeRequired,omitempty"`
+}
+
+type jsonLDNutrition struct {
+	Type                string `json:"@type"`
+	Calories            string `json:"calories,omitempty"`
+	ProteinContent      string `json:"proteinContent,omitempty"`
+	CarbohydrateContent string `json:"carbohydrateContent,omitempty"`
+	FatContent          string `json:"fatContent,omitempty"`
+	FiberContent        string `json:"fiberContent,omitempty"`
+	SugarContent        string `json:"sugarContent,omitempty"`
+	SodiumContent       string `json:"sodiumContent,omitempty"`
+	CholesterolContent  string `json:"cholesterolContent,omitempty"`
+}
+
+type jsonLDAggregateRating struct {
+	Type        string  `json:"@type"`
+	RatingValue float64 `json:"ratingValue"`
+	RatingCount int     `json:"ratingCount"`
+	BestRating  int     `json:"bestRating"`
+	WorstRating int     `json:"worstRating"`
+}
+
+// renderJSONLD writes a recipe as a schema.org Recipe, the markup search
+// engines read for rich results and the importer reads back.
+func renderJSONLD(recipe *models.Recipe, recipeURL string) ([]byte, error) {
+	doc := jsonLDRecipe{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               recipe.Title,
+		URL:                recipeURL,
+		RecipeIngredient:   []string{},
+		RecipeInstructions: []jsonLDStep{},
+		Keywords:           strings.Join(tagNames(recipe), ", "),
+	}
+
+	if recipe.Description != nil {
+		doc.Description = *recipe.Description
+	}
+	if recipe.ImageURL != nil {
+		doc.Image = *recipe.ImageURL
+	}
+	if recipe.SourceURL != nil {
+		doc.IsBasedOn = *recipe.SourceURL
+	}
+	if recipe.User.Name != "" {
+		doc.Author = &jsonLDPerson{Type: "Person", Name: recipe.User.Name}
+	}
+	if !recipe.CreatedAt.IsZero() {
+		doc.DatePublished = recipe.CreatedAt.Format("2006-01-02")
+	}
+
+	total := 0
+	if recipe.PrepTime != nil {
+		doc.PrepTime = isoDuration(*recipe.PrepTime)
+		total += *recipe.PrepTime
+	}
+	if recipe.CookTime != nil {
+		doc.CookTime = isoDuration(*recipe.CookTime)
+		total += *recipe.CookTime
+	}
+	if total > 0 {
+		doc.TotalTime = isoDuration(total)
+	}
+	if recipe.Servings != nil {
+		doc.RecipeYield = fmt.Sprintf("%d servings", *recipe.Servings)
+	}
+	if recipe.Type != nil {
+		doc.RecipeCategory = *recipe.Type
+	}
+
+	for _, ingredient := range sortedIngredients(recipe) {
+		doc.RecipeIngredient = append(doc.RecipeIngredient, ingredientLine(ingredient))
+	}
+	for i, instruction := range sortedInstructions(recipe) {
+		step := jsonLDStep{Type: "HowToStep", Position: i + 1, Text: instruction.Instruction}
+		if instruction.ImageURL != nil {
+			step.Image = *instruction.ImageURL
+		}
+		if instruction.TimerMinutes != nil {
+			step.TimeRequired = isoDuration(*instruction.TimerMinutes)
+		}
+		doc.RecipeInstructions = append(doc.RecipeInstructions, step)
+	}
+
+	if list := nutrients(recipe.Nutrition); len(list) > 0 {
+		nutrition := &jsonLDNutrition{Type: "NutritionInformation"}
+		fields := map[string]*string{
+			"Calories":      &nutrition.Calories,
+			"Protein":       &nutrition.ProteinContent,
+			"Carbohydrates": &nutrition.CarbohydrateContent,
+			"Fat":           &nutrition.FatContent,
+			"Fiber":         &nutrition.FiberContent,
+			"Sugar":         &nutrition.SugarContent,
+			"Sodium":        &nutrition.SodiumContent,
+			"Cholesterol":   &nutrition.CholesterolContent,
+		}
+		for _, n := range list {
+			*fields[n.label] = n.String()
+		}
+		nutrition.Calories = strings.Replace(nutrition.Calories, "kcal", "calories", 1)
+		doc.Nutrition = nutrition
+	}
+
+	if recipe.RatingCount > 0 {
+		doc.AggregateRating = &jsonLDAggregateRating{
+			Type:        "AggregateRating",
+			RatingValue: recipe.Rating,
+			RatingCount: recipe.RatingCount,
+			BestRating:  5,
+			WorstRating: 1,
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,289 @@
+package exporter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+)
+
+// pdfFont is one of the standard Type 1 fonts that every PDF reader
+// provides, so no font data has to be embedded.
+type pdfFont int
+
+const (
+	regular pdfFont = iota
+	bold
+	italic
+)
+
+var pdfFontNames = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique"}
+
+// Widths of the printable ASCII characters in thousandths of the font size,
+// from the Adobe font metrics. Helvetica-Oblique has the same widths as
+// Helvetica.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// winAnsiWidths holds the widths of the common characters above ASCII.
+// Other accented letters are approximated, which is close enough for
+// wrapping lines.
+var winAnsiWidths = map[byte]int{
+	0x80: 556, 0x85: 1000, 0x91: 222, 0x92: 222, 0x93: 333, 0x94: 333,
+	0x95: 350, 0x96: 556, 0x97: 1000, 0x99: 1000, 0xA0: 278, 0xA9: 737,
+	0xAE: 737, 0xB0: 400, 0xB7: 278, 0xBC: 834, 0xBD: 834, 0xBE: 834,
+	0xC6: 1000, 0xD7: 584, 0xDF: 611, 0xE6: 889, 0xF7: 584,
+}
+
+// width measures WinAnsi encoded text set in the font at the given size.
+func (f pdfFont) width(s string, size float64) float64 {
+	widths := &helveticaWidths
+	if f == bold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 32 && c < 127 {
+			total += widths[c-32]
+		} else if w, ok := winAnsiWidths[c]; ok {
+			total += w
+		} else if c >= 0xC0 && c < 0xDF {
+			total += 722
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// winAnsiSpecials maps the characters of the 0x80-0x9F range of
+// WinAnsiEncoding. Latin-1 characters keep their code.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// asciiFallbacks spells out characters the standard fonts lack but recipes
+// commonly use.
+var asciiFallbacks = map[rune]string{
+	'⅓': "1/3", '⅔': "2/3", '⅕': "1/5", '⅙': "1/6", '⅛': "1/8", '⅜': "3/8",
+	'⅝': "5/8", '⅞': "7/8", '⁄': "/", '−': "-", '‐': "-", '‑': "-", '′': "'",
+	'″': "\"", '\t': " ",
+}
+
+// winAnsi encodes text for the standard fonts. Characters they can't show
+// become question marks.
+func winAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 32 && r < 127, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case winAnsiSpecials[r] != 0:
+			b.WriteByte(winAnsiSpecials[r])
+		case asciiFallbacks[r] != "":
+			b.WriteString(asciiFallbacks[r])
+		case r < 32:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfString quotes encoded text as a PDF literal string.
+func pdfString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`)
+	return "(" + r.Replace(s) + ")"
+}
+
+// wrapText breaks encoded text into lines no wider than width. Words longer
+// than a line are split.
+func wrapText(font pdfFont, size float64, s string, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if font.width(candidate, size) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		for font.width(word, size) > width && len(word) > 1 {
+			n := len(word) - 1
+			for n > 1 && font.width(word[:n], size) > width {
+				n--
+			}
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// maxPDFImageWidth bounds the pixel width of embedded images, which is
+// plenty for a printed page and keeps files small.
+const maxPDFImageWidth = 1200
+
+// pdfImage is a JPEG image embedded in the document.
+type pdfImage struct {
+	data          []byte
+	width, height int
+}
+
+// newPDFImage flattens an image onto white, scales it down if it is larger
+// than needed and encodes it as JPEG.
+func newPDFImage(img image.Image) (*pdfImage, error) {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	out := flat
+	if flat.Bounds().Dx() > maxPDFImageWidth {
+		w := maxPDFImageWidth
+		h := flat.Bounds().Dy() * w / flat.Bounds().Dx()
+		if h < 1 {
+			h = 1
+		}
+		out = image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			sy := y * flat.Bounds().Dy() / h
+			for x := 0; x < w; x++ {
+				sx := x * flat.Bounds().Dx() / w
+				copy(out.Pix[out.PixOffset(x, y):out.PixOffset(x, y)+4], flat.Pix[flat.PixOffset(sx, sy):flat.PixOffset(sx, sy)+4])
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &pdfImage{data: buf.Bytes(), width: out.Bounds().Dx(), height: out.Bounds().Dy()}, nil
+}
+
+// pdfDocument collects the pages of a PDF as content streams and writes the
+// file structure around them.
+type pdfDocument struct {
+	title  string
+	pages  []*bytes.Buffer
+	images []*pdfImage
+}
+
+// addImage embeds an image and returns its resource name.
+func (d *pdfDocument) addImage(img *pdfImage) string {
+	d.images = append(d.images, img)
+	return fmt.Sprintf("Im%d", len(d.images)-1)
+}
+
+// bytes writes the document. Objects are numbered as follows: the catalog,
+// the page tree, the info dictionary, the fonts, the images and then a page
+// and its content stream for every page.
+func (d *pdfDocument) bytes() ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			out.WriteString("stream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	const fontsObj = 4
+	imagesObj := fontsObj + len(pdfFontNames)
+	pagesObj := imagesObj + len(d.images)
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pagesObj+2*i)
+	}
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for i := range pdfFontNames {
+		fmt.Fprintf(&resources, " /F%d %d 0 R", i+1, fontsObj+i)
+	}
+	resources.WriteString(" >>")
+	if len(d.images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for i := range d.images {
+			fmt.Fprintf(&resources, " /Im%d %d 0 R", i, imagesObj+i)
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] /Resources %s >>",
+		strings.Join(kids, " "), len(d.pages), pageWidth, pageHeight, resources.String()), nil)
+	object(fmt.Sprintf("<< /Title %s /Producer (Yummio) >>", pdfString(winAnsi(d.title))), nil)
+	for _, name := range pdfFontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name), nil)
+	}
+	for _, img := range d.images {
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>",
+			img.width, img.height, len(img.data)), img.data)
+	}
+	for i, page := range d.pages {
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>", pagesObj+2*i+1), nil)
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", content.Len()), content.Bytes())
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes(), nil
+}
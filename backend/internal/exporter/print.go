@@ -0,0 +1,279 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"strings"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HTML Format = "html"
+	PDF  Format = "pdf"
+)
+
+// ParsePrintFormat validates a print format name as sent by clients.
+func ParsePrintFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case HTML, "":
+		return HTML, nil
+	case PDF:
+		return PDF, nil
+	}
+	return "", errors.New("format must be html or pdf")
+}
+
+// PrintOptions controls how printed recipes are laid out.
+type PrintOptions struct {
+	// TwoColumn sets the ingredients beside the instructions in smaller
+	// type, so most recipes fit on one page.
+	TwoColumn bool
+}
+
+// Booklet is a set of recipes printed as one document. Booklets with a
+// title, such as printed collections, start with a cover page listing the
+// recipes.
+type Booklet struct {
+	Title       string
+	Description string
+	Recipes     []*models.Recipe
+
+	// Images holds the decoded recipe images by recipe ID for PDF output.
+	// HTML links to the recipe's image URL instead.
+	Images map[uuid.UUID]image.Image
+}
+
+// Print renders a booklet as a printable HTML page or a PDF document.
+func Print(book *Booklet, format Format, opts PrintOptions) ([]byte, error) {
+	recipes := make([]printRecipe, len(book.Recipes))
+	for i, recipe := range book.Recipes {
+		recipes[i] = newPrintRecipe(recipe)
+	}
+
+	switch format {
+	case HTML:
+		return renderHTML(book, recipes, opts)
+	case PDF:
+		return renderPDF(book, recipes, opts)
+	}
+	return nil, errors.New("unsupported print format")
+}
+
+// printRecipe is a recipe prepared for printing, shared by the HTML and PDF
+// layouts.
+type printRecipe struct {
+	ID                 uuid.UUID
+	Title              string
+	Description        string
+	ImageURL           string
+	Details            []string
+	Ingredients        []string
+	Steps              []printStep
+	Nutrition          []printNutrient
+	NutritionEstimated bool
+	Source             string
+}
+
+type printStep struct {
+	Text  string
+	Timer string
+}
+
+type printNutrient struct {
+	Label string
+	Value string
+}
+
+func newPrintRecipe(recipe *models.Recipe) printRecipe {
+	p := printRecipe{
+		ID:    recipe.ID,
+		Title: recipe.Title,
+	}
+	if recipe.Description != nil {
+		p.Description = *recipe.Description
+	}
+	if recipe.ImageURL != nil {
+		p.ImageURL = *recipe.ImageURL
+	}
+	if recipe.SourceURL != nil {
+		p.Source = *recipe.SourceURL
+	}
+	if recipe.Nutrition != nil {
+		p.NutritionEstimated = recipe.Nutrition.Calculated
+	}
+	for _, n := range nutrients(recipe.Nutrition) {
+		p.Nutrition = append(p.Nutrition, printNutrient{Label: n.label, Value: n.String()})
+	}
+
+	if recipe.PrepTime != nil {
+		p.Details = append(p.Details, "Prep "+minutesText(*recipe.PrepTime))
+	}
+	if recipe.CookTime != nil {
+		p.Details = append(p.Details, "Cook "+minutesText(*recipe.CookTime))
+	}
+	if recipe.Servings != nil {
+		p.Details = append(p.Details, fmt.Sprintf("Serves %d", *recipe.Servings))
+	}
+	if recipe.Difficulty != nil && *recipe.Difficulty != "" {
+		p.Details = append(p.Details, strings.ToUpper((*recipe.Difficulty)[:1])+(*recipe.Difficulty)[1:])
+	}
+	if recipe.User.Name != "" {
+		p.Details = append(p.Details, "By "+recipe.User.Name)
+	}
+
+	for _, ingredient := range sortedIngredients(recipe) {
+		p.Ingredients = append(p.Ingredients, ingredientLine(ingredient))
+	}
+	for _, instruction := range sortedInstructions(recipe) {
+		step := printStep{Text: instruction.Instruction}
+		if instruction.TimerMinutes != nil {
+			step.Timer = minutesText(*instruction.TimerMinutes)
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return p
+}
+
+var printTemplate = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+@page { margin: 15mm; }
+* { box-sizing: border-box; }
+body { margin: 0 auto; max-width: 48rem; padding: 1.5rem; color: #111; font: 11pt/1.45 Georgia, "Times New Roman", serif; }
+h1, h2, h3, .details, .nutrition, .contents, footer { font-family: "Helvetica Neue", Arial, sans-serif; }
+h1 { margin: 0 0 .25rem; font-size: 22pt; line-height: 1.2; }
+h2 { margin: 1.25rem 0 .5rem; font-size: 13pt; text-transform: uppercase; letter-spacing: .05em; border-bottom: 1px solid #999; padding-bottom: .2rem; }
+.details { margin: 0 0 .75rem; padding: 0; list-style: none; color: #444; font-size: 10pt; }
+.details li { display: inline; }
+.details li + li::before { content: " · "; }
+.description { margin: 0 0 .75rem; }
+.photo { display: block; max-width: 100%; max-height: 9cm; margin: .5rem 0; object-fit: cover; }
+.ingredients { margin: 0; padding-left: 1.2rem; }
+.ingredients li { margin-bottom: .2rem; }
+.instructions { margin: 0; padding-left: 1.5rem; }
+.instructions li { margin-bottom: .5rem; }
+.timer { color: #444; font-style: italic; }
+.nutrition { margin-top: 1.25rem; border: 1px solid #999; padding: .5rem .75rem; break-inside: avoid; page-break-inside: avoid; }
+.nutrition h2 { margin-top: 0; border: 0; }
+.nutrition dl { display: grid; grid-template-columns: repeat(4, 1fr); gap: .25rem 1rem; margin: 0; }
+.nutrition dt { font-size: 8.5pt; color: #444; }
+.nutrition dd { margin: 0; font-weight: bold; }
+.nutrition p { margin: .4rem 0 0; font-size: 8.5pt; font-style: italic; color: #444; }
+footer { margin-top: 1rem; font-size: 8.5pt; color: #444; word-break: break-all; }
+.recipe + .recipe, .cover + .recipe { break-before: page; page-break-before: always; }
+.cover h1 { font-size: 30pt; margin-top: 3rem; }
+.contents { padding-left: 1.5rem; }
+.contents a { color: inherit; text-decoration: none; }
+.two-column { font-size: 9.5pt; }
+.two-column h1 { font-size: 18pt; }
+.two-column .photo { max-height: 6cm; }
+.two-column .columns { display: grid; grid-template-columns: 2fr 3fr; gap: 1.5rem; }
+.two-column h2 { font-size: 11pt; margin-top: .75rem; }
+@media print { body { max-width: none; padding: 0; } }
+</style>
+</head>
+<body{{if .TwoColumn}} class="two-column"{{end}}>
+{{- if .Cover}}
+<section class="cover">
+<h1>{{.Title}}</h1>
+{{- with .Description}}
+<p class="description">{{.}}</p>
+{{- end}}
+<h2>Contents</h2>
+<ol class="contents">
+{{- range .Recipes}}
+<li><a href="#recipe-{{.ID}}">{{.Title}}</a></li>
+{{- end}}
+</ol>
+</section>
+{{- end}}
+{{- range .Recipes}}
+<article class="recipe" id="recipe-{{.ID}}">
+<h1>{{.Title}}</h1>
+{{- with .Details}}
+<ul class="details">{{range .}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+{{- with .Description}}
+<p class="description">{{.}}</p>
+{{- end}}
+{{- with .ImageURL}}
+<img class="photo" src="{{.}}" alt="">
+{{- end}}
+<div class="columns">
+{{- with .Ingredients}}
+<section>
+<h2>Ingredients</h2>
+<ul class="ingredients">
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+{{- with .Steps}}
+<section>
+<h2>Instructions</h2>
+<ol class="instructions">
+{{- range .}}
+<li>{{.Text}}{{with .Timer}} <span class="timer">(Timer: {{.}})</span>{{end}}</li>
+{{- end}}
+</ol>
+</section>
+{{- end}}
+</div>
+{{- if .Nutrition}}
+<section class="nutrition">
+<h2>Nutrition per serving</h2>
+<dl>
+{{- range .Nutrition}}
+<div><dt>{{.Label}}</dt><dd>{{.Value}}</dd></div>
+{{- end}}
+</dl>
+{{- if .NutritionEstimated}}
+<p>Estimated from the ingredients.</p>
+{{- end}}
+</section>
+{{- end}}
+{{- with .Source}}
+<footer>Source: {{.}}</footer>
+{{- end}}
+</article>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML writes a booklet as a standalone page styled for printing.
+func renderHTML(book *Booklet, recipes []printRecipe, opts PrintOptions) ([]byte, error) {
+	data := struct {
+		Title       string
+		Description string
+		Cover       bool
+		TwoColumn   bool
+		Recipes     []printRecipe
+	}{
+		Title:       book.Title,
+		Description: book.Description,
+		Cover:       book.Title != "",
+		TwoColumn:   opts.TwoColumn,
+		Recipes:     recipes,
+	}
+	if !data.Cover && len(recipes) > 0 {
+		data.Title = recipes[0].Title
+	}
+
+	var buf bytes.Buffer
+	if err := printTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,348 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size and margins in points.
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	pageMargin   = 48.0
+	footerHeight = 24.0
+	columnGap    = 20.0
+)
+
+// pdfStyle holds the type sizes of a layout.
+type pdfStyle struct {
+	title, heading, body, small float64
+	imageHeight                 float64
+}
+
+var (
+	normalStyle  = pdfStyle{title: 22, heading: 13, body: 11, small: 9, imageHeight: 220}
+	compactStyle = pdfStyle{title: 18, heading: 11, body: 9, small: 8, imageHeight: 140}
+)
+
+// pdfLayout flows text down the pages of a document. It writes into the
+// column starting at x, breaking to the next page when the column is full.
+type pdfLayout struct {
+	doc    *pdfDocument
+	style  pdfStyle
+	labels []string // footer text of every page
+	page   int
+	y      float64 // top of the free space on the current page
+	x      float64
+	width  float64
+}
+
+func newPDFLayout(doc *pdfDocument, style pdfStyle) *pdfLayout {
+	return &pdfLayout{doc: doc, style: style, page: -1}
+}
+
+// newPage starts a page labelled in the footer with label.
+func (l *pdfLayout) newPage(label string) {
+	l.doc.pages = append(l.doc.pages, new(bytes.Buffer))
+	l.labels = append(l.labels, label)
+	l.page = len(l.doc.pages) - 1
+	l.y = pageHeight - pageMargin
+	l.x = pageMargin
+	l.width = pageWidth - 2*pageMargin
+}
+
+// ensure moves to the next page unless height fits in the current column.
+// A column that overflows continues on a page another column may already
+// have started.
+func (l *pdfLayout) ensure(height float64) {
+	if l.y-height >= pageMargin+footerHeight {
+		return
+	}
+	if l.page+1 == len(l.doc.pages) {
+		x, width := l.x, l.width
+		l.newPage(l.labels[l.page])
+		l.x, l.width = x, width
+	} else {
+		l.page++
+	}
+	l.y = pageHeight - pageMargin
+}
+
+func (l *pdfLayout) content() *bytes.Buffer {
+	return l.doc.pages[l.page]
+}
+
+// text draws encoded text with its baseline at y.
+func (l *pdfLayout) text(font pdfFont, size, x, y float64, s string) {
+	fmt.Fprintf(l.content(), "BT /F%d %.1f Tf %.2f %.2f Td %s Tj ET\n", font+1, size, x, y, pdfString(s))
+}
+
+// paragraph wraps text into the column, indented by indent. A prefix such
+// as a step number is set in the indentation of the first line.
+func (l *pdfLayout) paragraph(font pdfFont, size float64, s string, indent float64, prefix string) {
+	leading := size * 1.35
+	for i, line := range wrapText(font, size, winAnsi(s), l.width-indent) {
+		l.ensure(leading)
+		baseline := l.y - size
+		if i == 0 && prefix != "" {
+			l.text(font, size, l.x, baseline, winAnsi(prefix))
+		}
+		l.text(font, size, l.x+indent, baseline, line)
+		l.y -= leading
+	}
+}
+
+// heading draws a section heading with a rule under it, kept on the same
+// page as the first lines that follow.
+func (l *pdfLayout) heading(title string) {
+	size := l.style.heading
+	l.ensure(size*1.6 + 3*l.style.body)
+	l.y -= size * 0.6
+	baseline := l.y - size
+	l.text(bold, size, l.x, baseline, winAnsi(strings.ToUpper(title)))
+	fmt.Fprintf(l.content(), "0.6 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n",
+		l.x, baseline-4, l.x+l.width, baseline-4)
+	l.y -= size + 10
+}
+
+// space adds vertical space, unless at the top of a page.
+func (l *pdfLayout) space(height float64) {
+	if l.y < pageHeight-pageMargin {
+		l.y -= height
+	}
+}
+
+// renderPDF lays out a booklet as an A4 PDF document.
+func renderPDF(book *Booklet, recipes []printRecipe, opts PrintOptions) ([]byte, error) {
+	style := normalStyle
+	if opts.TwoColumn {
+		style = compactStyle
+	}
+
+	doc := &pdfDocument{title: book.Title}
+	if doc.title == "" && len(recipes) > 0 {
+		doc.title = recipes[0].Title
+	}
+
+	body := newPDFLayout(doc, style)
+	starts := make([]int, len(recipes))
+	for i := range recipes {
+		starts[i] = len(doc.pages)
+		var img *pdfImage
+		if src := book.Images[recipes[i].ID]; src != nil {
+			var err error
+			if img, err = newPDFImage(src); err != nil {
+				return nil, err
+			}
+		}
+		layoutRecipe(body, &recipes[i], img, opts)
+	}
+	labels := body.labels
+
+	if book.Title != "" {
+		// The cover's length doesn't depend on the page numbers it lists,
+		// so a first pass tells how far the recipes are pushed back.
+		coverPages := len(layoutCover(book, recipes, starts, 0).doc.pages)
+		cover := layoutCover(book, recipes, starts, coverPages)
+		doc.pages = append(cover.doc.pages, doc.pages...)
+		labels = append(cover.labels, labels...)
+	}
+
+	for i, page := range doc.pages {
+		size := 8.0
+		number := winAnsi(fmt.Sprintf("%d / %d", i+1, len(doc.pages)))
+		label := winAnsi(labels[i])
+		if width := pageWidth - 2*pageMargin - 60; regular.width(label, size) > width {
+			label = wrapText(regular, size, label, width)[0] + "..."
+		}
+		fmt.Fprintf(page, "0.4 g BT /F1 %.1f Tf %.2f %.2f Td %s Tj ET\n", size, pageMargin, pageMargin, pdfString(label))
+		fmt.Fprintf(page, "BT /F1 %.1f Tf %.2f %.2f Td %s Tj ET 0 g\n", size,
+			pageWidth-pageMargin-regular.width(number, size), pageMargin, pdfString(number))
+	}
+
+	return doc.bytes()
+}
+
+// layoutRecipe writes a recipe starting on a new page.
+func layoutRecipe(l *pdfLayout, recipe *printRecipe, img *pdfImage, opts PrintOptions) {
+	style := l.style
+	l.newPage(recipe.Title)
+
+	l.paragraph(bold, style.title, recipe.Title, 0, "")
+	if len(recipe.Details) > 0 {
+		l.space(2)
+		l.paragraph(italic, style.small+1, strings.Join(recipe.Details, "  ·  "), 0, "")
+	}
+	if recipe.Description != "" {
+		l.space(6)
+		l.paragraph(regular, style.body, recipe.Description, 0, "")
+	}
+
+	if img != nil {
+		width := l.width
+		height := width * float64(img.height) / float64(img.width)
+		if height > style.imageHeight {
+			height = style.imageHeight
+			width = height * float64(img.width) / float64(img.height)
+		}
+		l.space(8)
+		l.ensure(height)
+		name := l.doc.addImage(img)
+		fmt.Fprintf(l.content(), "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", width, height, l.x, l.y-height, name)
+		l.y -= height
+	}
+
+	if opts.TwoColumn && len(recipe.Ingredients) > 0 && len(recipe.Steps) > 0 {
+		// Both columns start at the same point and may flow onto further
+		// pages separately. The recipe continues below the longer one.
+		startPage, startY := l.page, l.y
+		left := (l.width - columnGap) * 0.4
+		right := l.width - columnGap - left
+
+		l.width = left
+		layoutIngredients(l, recipe)
+		endPage, endY := l.page, l.y
+
+		l.page, l.y = startPage, startY
+		l.x += left + columnGap
+		l.width = right
+		layoutSteps(l, recipe)
+		if l.page < endPage || (l.page == endPage && l.y > endY) {
+			l.page, l.y = endPage, endY
+		}
+		l.x, l.width = pageMargin, pageWidth-2*pageMargin
+	} else {
+		layoutIngredients(l, recipe)
+		layoutSteps(l, recipe)
+	}
+
+	layoutNutrition(l, recipe)
+
+	if recipe.Source != "" {
+		l.space(10)
+		l.paragraph(italic, style.small, "Source: "+recipe.Source, 0, "")
+	}
+}
+
+func layoutIngredients(l *pdfLayout, recipe *printRecipe) {
+	if len(recipe.Ingredients) == 0 {
+		return
+	}
+	l.space(8)
+	l.heading("Ingredients")
+	for _, line := range recipe.Ingredients {
+		l.paragraph(regular, l.style.body, line, l.style.body, "•")
+		l.y -= l.style.body * 0.2
+	}
+}
+
+func layoutSteps(l *pdfLayout, recipe *printRecipe) {
+	if len(recipe.Steps) == 0 {
+		return
+	}
+	l.space(8)
+	l.heading("Instructions")
+	indent := l.style.body * 1.8
+	for i, step := range recipe.Steps {
+		l.paragraph(regular, l.style.body, step.Text, indent, fmt.Sprintf("%d.", i+1))
+		if step.Timer != "" {
+			x := l.x
+			l.x += indent
+			l.width -= indent
+			l.paragraph(italic, l.style.small, "Timer: "+step.Timer, 0, "")
+			l.x = x
+			l.width += indent
+		}
+		l.y -= l.style.body * 0.5
+	}
+}
+
+// layoutNutrition draws the nutrition values in a framed panel, four to a
+// row, kept together on one page.
+func layoutNutrition(l *pdfLayout, recipe *printRecipe) {
+	if len(recipe.Nutrition) == 0 {
+		return
+	}
+
+	style := l.style
+	const perRow, padding = 4, 8.0
+	rowHeight := style.small + style.body + 8
+	rows := (len(recipe.Nutrition) + perRow - 1) / perRow
+	height := 2*padding + style.heading + 8 + float64(rows)*rowHeight
+	if recipe.NutritionEstimated {
+		height += style.small + 4
+	}
+
+	l.space(14)
+	l.ensure(height)
+	top := l.y
+	fmt.Fprintf(l.content(), "0.6 G 0.5 w %.2f %.2f %.2f %.2f re S 0 G\n", l.x, top-height, l.width, height)
+
+	x := l.x + padding
+	y := top - padding - style.heading
+	l.text(bold, style.heading, x, y, winAnsi(strings.ToUpper("Nutrition per serving")))
+	y -= 8
+
+	cell := (l.width - 2*padding) / perRow
+	for i, n := range recipe.Nutrition {
+		if i%perRow == 0 {
+			y -= rowHeight
+		}
+		cx := x + float64(i%perRow)*cell
+		l.text(regular, style.small, cx, y+style.body+2, winAnsi(n.Label))
+		l.text(bold, style.body, cx, y, winAnsi(n.Value))
+	}
+	if recipe.NutritionEstimated {
+		l.text(italic, style.small, x, y-style.small-4, winAnsi("Estimated from the ingredients."))
+	}
+	l.y = top - height
+}
+
+// layoutCover writes the cover page of a booklet with its table of
+// contents into a document of its own. Recipe i starts on page starts[i] of
+// the body, which follows offset cover pages.
+func layoutCover(book *Booklet, recipes []printRecipe, starts []int, offset int) *pdfLayout {
+	l := newPDFLayout(&pdfDocument{}, normalStyle)
+	l.newPage(book.Title)
+
+	l.y -= 80
+	l.paragraph(bold, 30, book.Title, 0, "")
+	if book.Description != "" {
+		l.space(10)
+		l.paragraph(regular, 12, book.Description, 0, "")
+	}
+	l.space(4)
+	count := fmt.Sprintf("%d recipes", len(recipes))
+	if len(recipes) == 1 {
+		count = "1 recipe"
+	}
+	l.paragraph(italic, 10, count, 0, "")
+
+	l.space(24)
+	l.heading("Contents")
+	size := l.style.body
+	for i, recipe := range recipes {
+		number := winAnsi(fmt.Sprint(starts[i] + offset + 1))
+		numberWidth := regular.width(number, size)
+
+		// Titles are cut to one line so the cover's length is fixed.
+		title := winAnsi(recipe.Title)
+		if width := l.width - numberWidth - 30; regular.width(title, size) > width {
+			title = wrapText(regular, size, title, width-regular.width("...", size))[0] + "..."
+		}
+
+		l.ensure(size * 1.6)
+		baseline := l.y - size
+		l.text(regular, size, l.x, baseline, title)
+		l.text(regular, size, l.x+l.width-numberWidth, baseline, number)
+
+		dotsFrom := l.x + regular.width(title, size) + 6
+		dotsTo := l.x + l.width - numberWidth - 6
+		if dots := int((dotsTo - dotsFrom) / regular.width(".", size)); dots > 0 {
+			l.text(regular, size, dotsFrom, baseline, strings.Repeat(".", dots))
+		}
+		l.y -= size * 1.6
+	}
+	return l
+}
@@ -0,0 +1,132 @@
+package exporter_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"yummio-backend/internal/exporter"
+	"yummio-backend/internal/importer"
+	"yummio-backend/internal/models"
+)
+
+func roundTripRecipe() *models.Recipe {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	integer := func(i int) *int { return &i }
+
+	return &models.Recipe{
+		Title:       "Savory Crepes",
+		Description: str("Thin crepes for any filling."),
+		Servings:    integer(4),
+		PrepTime:    integer(10),
+		CookTime:    integer(20),
+		Difficulty:  str("easy"),
+		Type:        str("dinner"),
+		SourceURL:   str("https://example.com/crepes"),
+		Tags:        []models.Tag{{Name: "French"}, {Name: "Quick"}},
+		Ingredients: []models.Ingredient{
+			{Name: "black pepper", Notes: str("to taste"), OrderIndex: 0},
+			{Name: "flour", Amount: num(1.5), Unit: str("cup"), Notes: str("sifted"), OrderIndex: 1},
+			{Name: "eggs", Amount: num(2), OrderIndex: 2},
+			{Name: "olive oil", Amount: num(2), Unit: str("tbsp"), OrderIndex: 3},
+			{Name: "sea salt", Amount: num(0.5), Unit: str("tsp"), OrderIndex: 4},
+		},
+		Instructions: []models.Instruction{
+			{Step: 1, Instruction: "Whisk the flour and eggs until smooth."},
+			{Step: 2, Instruction: "Heat the olive oil and cook each crepe with a little sea salt.", TimerMinutes: integer(5)},
+		},
+	}
+}
+
+// ingredientLine writes an ingredient compactly for comparison.
+func ingredientLine(name string, amount *float64, unit, notes *string) string {
+	parts := []string{}
+	if amount != nil {
+		parts = append(parts, strconv.FormatFloat(*amount, 'f', -1, 64))
+	}
+	if unit != nil && *unit != "" {
+		parts = append(parts, *unit)
+	}
+	parts = append(parts, name)
+	if notes != nil && *notes != "" {
+		parts = append(parts, "("+*notes+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	tests := []struct {
+		format exporter.Format
+		read   func(data []byte) (*models.RecipeCreateRequest, error)
+	}{
+		{exporter.Cooklang, func(data []byte) (*models.RecipeCreateRequest, error) {
+			return importer.ParseCooklang(string(data), "crepes")
+		}},
+		{exporter.JSONLD, func(data []byte) (*models.RecipeCreateRequest, error) {
+			page := `<html><head><script type="application/ld+json">` + string(data) + `</script></head></html>`
+			recipe, _, err := importer.Extract(strings.NewReader(page), "")
+			return recipe, err
+		}},
+	}
+
+	recipe := roundTripRecipe()
+	var wantIngredients []string
+	for _, ingredient := range recipe.Ingredients {
+		wantIngredients = append(wantIngredients, ingredientLine(ingredient.Name, ingredient.Amount, ingredient.Unit, ingredient.Notes))
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			data, err := exporter.Export(recipe, tt.format, "")
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			got, err := tt.read(data)
+			if err != nil {
+				t.Fatalf("reading the export back: %v\n%s", err, data)
+			}
+
+			if got.Title != recipe.Title {
+				t.Errorf("title = %q, want %q", got.Title, recipe.Title)
+			}
+			if got.Description == nil || *got.Description != *recipe.Description {
+				t.Errorf("description = %v, want %q", got.Description, *recipe.Description)
+			}
+			if got.Servings == nil || *got.Servings != *recipe.Servings {
+				t.Errorf("servings = %v, want %d", got.Servings, *recipe.Servings)
+			}
+			if got.PrepTime == nil || *got.PrepTime != *recipe.PrepTime {
+				t.Errorf("prep time = %v, want %d", got.PrepTime, *recipe.PrepTime)
+			}
+			if got.CookTime == nil || *got.CookTime != *recipe.CookTime {
+				t.Errorf("cook time = %v, want %d", got.CookTime, *recipe.CookTime)
+			}
+			if want := []string{"French", "Quick"}; !reflect.DeepEqual(got.Tags, want) && !reflect.DeepEqual(got.Tags, append([]string{"dinner"}, want...)) {
+				t.Errorf("tags = %q, want %q", got.Tags, want)
+			}
+
+			var ingredients []string
+			for _, ingredient := range got.Ingredients {
+				ingredients = append(ingredients, ingredientLine(ingredient.Name, ingredient.Amount, ingredient.Unit, ingredient.Notes))
+			}
+			if !reflect.DeepEqual(ingredients, wantIngredients) {
+				t.Errorf("ingredients = %q, want %q\n%s", ingredients, wantIngredients, data)
+			}
+
+			if len(got.Instructions) != len(recipe.Instructions) {
+				t.Fatalf("got %d instructions, want %d\n%s", len(got.Instructions), len(recipe.Instructions), data)
+			}
+			for i, instruction := range got.Instructions {
+				want := recipe.Instructions[i]
+				if instruction.Step != want.Step || !strings.HasPrefix(instruction.Instruction, want.Instruction) {
+					t.Errorf("instruction %d = %d %q, want %d %q", i, instruction.Step, instruction.Instruction, want.Step, want.Instruction)
+				}
+				if (instruction.TimerMinutes == nil) != (want.TimerMinutes == nil) ||
+					(want.TimerMinutes != nil && *instruction.TimerMinutes != *want.TimerMinutes) {
+					t.Errorf("instruction %d timer = %v, want %v", i, instruction.TimerMinutes, want.TimerMinutes)
+				}
+			}
+		})
+	}
+}
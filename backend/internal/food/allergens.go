@@ -0,0 +1,141 @@
+package food
+
+import "strings"
+
+// AllergenClasses lists the allergen classes recipes can be filtered by.
+var AllergenClasses = []string{
+	"celery",
+	"dairy",
+	"egg",
+	"fish",
+	"gluten",
+	"mustard",
+	"nuts",
+	"peanuts",
+	"sesame",
+	"shellfish",
+	"soy",
+}
+
+// allergenAliases maps other common names to allergen classes.
+var allergenAliases = map[string]string{
+	"milk":       "dairy",
+	"lactose":    "dairy",
+	"eggs":       "egg",
+	"wheat":      "gluten",
+	"nut":        "nuts",
+	"tree nuts":  "nuts",
+	"peanut":     "peanuts",
+	"soya":       "soy",
+	"crustacean": "shellfish",
+	"mollusc":    "shellfish",
+}
+
+// allergens maps allergen classes to the ingredient names that contain them.
+var allergens = map[string]*matcher{
+	"celery": newMatcher(
+		[]string{"celery", "celeriac", "celery salt"},
+		nil,
+	),
+	"dairy": newMatcher(
+		[]string{
+			"milk", "butter", "buttermilk", "cream", "cheese", "yogurt", "yoghurt",
+			"parmesan", "parmigiano", "pecorino", "mozzarella", "cheddar", "ricotta",
+			"feta", "mascarpone", "gouda", "brie", "camembert", "gruyere", "halloumi",
+			"paneer", "ghee", "whey", "creme fraiche", "crème fraîche", "custard",
+			"kefir", "quark", "ice cream",
+		},
+		[]string{
+			"coconut milk", "coconut cream", "almond milk", "oat milk", "soy milk",
+			"rice milk", "cashew milk", "peanut butter", "almond butter",
+			"cashew butter", "nut butter", "cocoa butter", "apple butter",
+			"cream of tartar", "dairy-free", "dairy free", "vegan", "non-dairy",
+			"plant-based",
+		},
+	),
+	"egg": newMatcher(
+		[]string{"egg", "egg yolk", "egg white", "mayonnaise", "mayo", "meringue", "aioli", "custard"},
+		[]string{"egg-free", "egg free", "vegan"},
+	),
+	"fish": newMatcher(
+		[]string{
+			"fish", "fish sauce", "salmon", "tuna", "cod", "anchovy", "sardine",
+			"trout", "mackerel", "haddock", "halibut", "tilapia", "sea bass",
+			"snapper", "swordfish", "herring", "pollock", "worcestershire",
+		},
+		nil,
+	),
+	"gluten": newMatcher(
+		[]string{
+			"wheat", "flour", "bread", "breadcrumb", "panko", "pasta", "spaghetti",
+			"macaroni", "penne", "fusilli", "linguine", "fettuccine", "lasagna",
+			"lasagne", "noodle", "couscous", "semolina", "barley", "rye", "spelt",
+			"bulgur", "farro", "seitan", "tortilla", "pita", "cracker", "biscuit",
+			"pastry", "phyllo", "filo", "beer", "malt", "soy sauce", "crouton",
+			"bun", "bagel", "baguette", "brioche", "ciabatta", "naan", "wonton",
+			"orzo", "gnocchi",
+		},
+		[]string{
+			"rice flour", "almond flour", "coconut flour", "corn flour",
+			"chickpea flour", "buckwheat flour", "tapioca flour", "potato flour",
+			"gluten-free", "gluten free", "rice noodle", "glass noodle",
+			"corn tortilla", "rice pasta",
+		},
+	),
+	"mustard": newMatcher(
+		[]string{"mustard", "mustard seed", "dijon"},
+		nil,
+	),
+	"nuts": newMatcher(
+		[]string{
+			"nut", "almond", "walnut", "pecan", "cashew", "pistachio", "hazelnut",
+			"macadamia", "brazil nut", "pine nut", "praline", "marzipan",
+			"frangipane", "nutella",
+		},
+		[]string{"nut-free", "nut free"},
+	),
+	"peanuts": newMatcher(
+		[]string{"peanut", "groundnut", "satay"},
+		nil,
+	),
+	"sesame": newMatcher(
+		[]string{"sesame", "sesame oil", "tahini"},
+		nil,
+	),
+	"shellfish": newMatcher(
+		[]string{
+			"shrimp", "prawn", "crab", "lobster", "scallop", "mussel", "clam",
+			"oyster", "crayfish", "langoustine", "squid", "calamari", "octopus",
+		},
+		[]string{"oyster mushroom"},
+	),
+	"soy": newMatcher(
+		[]string{"soy", "soya", "soybean", "soy sauce", "tofu", "edamame", "miso", "tempeh", "tamari"},
+		nil,
+	),
+}
+
+// NormalizeAllergen returns the allergen class for a name or alias.
+func NormalizeAllergen(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := allergenAliases[name]; ok {
+		return alias, true
+	}
+	_, ok := allergens[name]
+	return name, ok
+}
+
+// AllergensOf returns the allergen classes present in a list of ingredient
+// names, in the order of AllergenClasses.
+func AllergensOf(names []string) []string {
+	found := []string{}
+	for _, class := range AllergenClasses {
+		for _, name := range names {
+			if allergens[class].matches(name) {
+				found = append(found, class)
+				break
+			}
+		}
+	}
+	return found
+}
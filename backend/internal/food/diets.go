@@ -0,0 +1,108 @@
+package food
+
+import "strings"
+
+// DietLabels lists the dietary labels derived from recipe ingredients.
+var DietLabels = []string{
+	"vegan",
+	"vegetarian",
+	"gluten-free",
+	"keto",
+}
+
+// meat matches ingredients from slaughtered animals.
+var meat = newMatcher(
+	[]string{
+		"meat", "beef", "steak", "veal", "pork", "ham", "bacon", "pancetta",
+		"prosciutto", "chorizo", "salami", "pepperoni", "sausage", "lamb",
+		"mutton", "goat", "venison", "rabbit", "chicken", "turkey", "duck",
+		"goose", "quail", "mince", "ground beef", "meatball", "burger", "oxtail",
+		"liver", "lard", "suet", "gelatin", "gelatine", "bone broth",
+	},
+	[]string{
+		"vegan", "vegetarian", "plant-based", "meatless", "meat-free",
+		"meat free", "mushroom", "jackfruit", "seitan", "tofu", "soy mince",
+	},
+)
+
+// animalProducts matches ingredients that vegetarians eat but vegans avoid,
+// besides dairy and eggs.
+var animalProducts = newMatcher(
+	[]string{"honey", "royal jelly", "beeswax"},
+	nil,
+)
+
+// highCarb matches ingredients that don't fit a ketogenic diet.
+var highCarb = newMatcher(
+	[]string{
+		"sugar", "brown sugar", "icing sugar", "honey", "maple syrup", "agave",
+		"molasses", "syrup", "jam", "flour", "bread", "breadcrumb", "pasta",
+		"spaghetti", "macaroni", "noodle", "rice", "potato", "sweet potato",
+		"corn", "cornstarch", "cornmeal", "polenta", "oat", "oatmeal", "quinoa",
+		"couscous", "barley", "bean", "lentil", "chickpea", "banana", "tortilla",
+		"cracker", "cereal", "pita", "bun", "bagel",
+	},
+	[]string{
+		"almond flour", "coconut flour", "sugar-free", "sugar free",
+		"cauliflower rice", "green bean", "rice vinegar", "rice wine vinegar",
+		"vanilla bean", "coffee bean", "cocoa bean",
+	},
+)
+
+// dietExclusions lists what each dietary label rules out.
+var dietExclusions = map[string][]*matcher{
+	"vegan":       {meat, allergens["fish"], allergens["shellfish"], allergens["dairy"], allergens["egg"], animalProducts},
+	"vegetarian":  {meat, allergens["fish"], allergens["shellfish"]},
+	"gluten-free": {allergens["gluten"]},
+	"keto":        {highCarb},
+}
+
+// DietaryLabels returns the labels a list of ingredient names qualifies for
+// and, for every other label, the reasons it was denied such as
+// "contains honey". Recipes without ingredients get no labels.
+func DietaryLabels(names []string) (labels []string, reasons map[string][]string) {
+	labels = []string{}
+	reasons = make(map[string][]string)
+	if len(names) == 0 {
+		return labels, reasons
+	}
+
+	for _, label := range DietLabels {
+		seen := make(map[string]bool)
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if seen[name] {
+				continue
+			}
+			for _, m := range dietExclusions[label] {
+				if m.matches(name) {
+					seen[name] = true
+					reasons[label] = append(reasons[label], "contains "+name)
+					break
+				}
+			}
+		}
+		if len(reasons[label]) == 0 {
+			labels = append(labels, label)
+		}
+	}
+
+	return labels, reasons
+}
+
+// NormalizeDietLabel returns the dietary label for a name, accepting
+// spellings such as "gluten free".
+func NormalizeDietLabel(name string) (string, bool) {
+	name = strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "-")
+	if name == "glutenfree" {
+		name = "gluten-free"
+	}
+	for _, label := range DietLabels {
+		if label == name {
+			return label, true
+		}
+	}
+	return name, false
+}
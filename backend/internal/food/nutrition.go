@@ -0,0 +1,199 @@
+package food
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/units"
+)
+
+//go:embed data/nutrition.csv
+var nutritionCSV string
+
+// Nutrients holds nutrient amounts. Macros are in grams, sodium and
+// cholesterol in milligrams.
+type Nutrients struct {
+	Calories    float64
+	Protein     float64
+	Carbs       float64
+	Fat         float64
+	Fiber       float64
+	Sugar       float64
+	Sodium      float64
+	Cholesterol float64
+}
+
+// Add adds other to n.
+func (n *Nutrients) Add(other Nutrients) {
+	n.Calories += other.Calories
+	n.Protein += other.Protein
+	n.Carbs += other.Carbs
+	n.Fat += other.Fat
+	n.Fiber += other.Fiber
+	n.Sugar += other.Sugar
+	n.Sodium += other.Sodium
+	n.Cholesterol += other.Cholesterol
+}
+
+// Scale returns n multiplied by factor.
+func (n Nutrients) Scale(factor float64) Nutrients {
+	return Nutrients{
+		Calories:    n.Calories * factor,
+		Protein:     n.Protein * factor,
+		Carbs:       n.Carbs * factor,
+		Fat:         n.Fat * factor,
+		Fiber:       n.Fiber * factor,
+		Sugar:       n.Sugar * factor,
+		Sodium:      n.Sodium * factor,
+		Cholesterol: n.Cholesterol * factor,
+	}
+}
+
+// nutritionEntry is a row of the bundled dataset.
+type nutritionEntry struct {
+	name    string
+	pattern *regexp.Regexp
+	per100g Nutrients
+	density float64 // g/ml, 0 if unknown
+	each    float64 // grams per piece, 0 if not counted in pieces
+}
+
+var nutritionTable = loadNutrition(nutritionCSV)
+
+// pieceUnits are units that count whole items, such as "2 eggs" or
+// "3 cloves garlic".
+var pieceUnits = map[string]bool{
+	"":       true,
+	"piece":  true,
+	"pieces": true,
+	"whole":  true,
+	"each":   true,
+	"clove":  true,
+	"cloves": true,
+	"slice":  true,
+	"slices": true,
+	"fillet": true,
+	"small":  true,
+	"medium": true,
+	"large":  true,
+}
+
+// packageGrams are typical weights of units that aren't measures.
+var packageGrams = map[string]float64{
+	"can":    400,
+	"cans":   400,
+	"stick":  113,
+	"sticks": 113,
+	"pinch":  0.4,
+	"dash":   0.6,
+}
+
+func loadNutrition(data string) []nutritionEntry {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("food: invalid nutrition dataset: %v", err))
+	}
+
+	var entries []nutritionEntry
+	for _, record := range records[1:] {
+		values := make([]float64, len(record)-1)
+		for i, field := range record[1:] {
+			if field == "" {
+				continue
+			}
+			if values[i], err = strconv.ParseFloat(field, 64); err != nil {
+				panic(fmt.Sprintf("food: invalid nutrition value for %s: %v", record[0], err))
+			}
+		}
+
+		entries = append(entries, nutritionEntry{
+			name:    record[0],
+			pattern: regexp.MustCompile(termPattern(record[0], goBoundary)),
+			per100g: Nutrients{
+				Calories:    values[0],
+				Protein:     values[1],
+				Carbs:       values[2],
+				Fat:         values[3],
+				Fiber:       values[4],
+				Sugar:       values[5],
+				Sodium:      values[6],
+				Cholesterol: values[7],
+			},
+			density: values[8],
+			each:    values[9],
+		})
+	}
+	return entries
+}
+
+// lookupNutrition finds the dataset entry for an ingredient. Names at the
+// end of the ingredient win because that is usually what it is ("chicken
+// stock" is stock), then longer names, so "sweet potato" is not read as
+// "potato".
+func lookupNutrition(name string) (*nutritionEntry, bool) {
+	name = strings.TrimSpace(strings.ToLower(name))
+
+	var match *nutritionEntry
+	matchAtEnd := false
+	for i := range nutritionTable {
+		entry := &nutritionTable[i]
+		locs := entry.pattern.FindAllStringIndex(name, -1)
+		if locs == nil {
+			continue
+		}
+
+		atEnd := locs[len(locs)-1][1] == len(name)
+		if match == nil || (atEnd && !matchAtEnd) || (atEnd == matchAtEnd && len(entry.name) > len(match.name)) {
+			match, matchAtEnd = entry, atEnd
+		}
+	}
+	return match, match != nil
+}
+
+// IngredientNutrients estimates the nutrients in an amount of an ingredient.
+// It returns false if the ingredient is not in the dataset or its amount
+// can't be converted to grams.
+func IngredientNutrients(name string, amount float64, unit string) (Nutrients, bool) {
+	entry, ok := lookupNutrition(name)
+	if !ok {
+		return Nutrients{}, false
+	}
+
+	grams, ok := entry.grams(name, amount, unit)
+	if !ok {
+		return Nutrients{}, false
+	}
+	return entry.per100g.Scale(grams / 100), true
+}
+
+func (e *nutritionEntry) grams(name string, amount float64, unit string) (float64, bool) {
+	if u, ok := units.Lookup(unit); ok {
+		if u.Kind == units.Weight {
+			return amount * u.Factor, true
+		}
+
+		density := e.density
+		if density == 0 {
+			density, _ = units.DensityOf(name)
+		}
+		if density == 0 {
+			return 0, false
+		}
+		return amount * u.Factor * density, true
+	}
+
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	if grams, ok := packageGrams[unit]; ok {
+		return amount * grams, true
+	}
+	if pieceUnits[unit] && e.each > 0 {
+		return amount * e.each, true
+	}
+	return 0, false
+}
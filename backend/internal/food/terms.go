@@ -0,0 +1,81 @@
+package food
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Word boundaries for Go and PostgreSQL regular expressions.
+const (
+	goBoundary  = `\b`
+	sqlBoundary = `\y`
+)
+
+// termPattern returns a regular expression matching a term and its plural
+// as whole words, using the given word boundary.
+func termPattern(term, boundary string) string {
+	term = strings.Join(strings.Fields(strings.ToLower(term)), " ")
+
+	stem, suffix := term, "(e?s)?"
+	switch {
+	case strings.HasSuffix(term, "ies"):
+		stem, suffix = strings.TrimSuffix(term, "ies"), "(y|ies)"
+	case len(term) > 1 && strings.HasSuffix(term, "y") && !strings.ContainsRune("aeiou", rune(term[len(term)-2])):
+		stem, suffix = strings.TrimSuffix(term, "y"), "(y|ies)"
+	case strings.HasSuffix(term, "oes"), strings.HasSuffix(term, "shes"),
+		strings.HasSuffix(term, "ches"), strings.HasSuffix(term, "xes"):
+		stem = strings.TrimSuffix(term, "es")
+	case strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") && !strings.HasSuffix(term, "us"):
+		stem = strings.TrimSuffix(term, "s")
+	}
+
+	// Words may be separated by spaces or hyphens
+	words := strings.Split(stem, " ")
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return boundary + strings.Join(words, "[ -]+") + suffix + boundary
+}
+
+// termsPattern joins the patterns of several terms into one alternation.
+func termsPattern(terms []string, boundary string) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = termPattern(term, boundary)
+	}
+	return strings.Join(patterns, "|")
+}
+
+// SQLTermPattern returns a PostgreSQL regular expression matching ingredient
+// names that contain the term, singular or plural, as whole words.
+func SQLTermPattern(term string) string {
+	return termPattern(term, sqlBoundary)
+}
+
+// matcher matches ingredient names against a list of terms, minus a list of
+// exceptions such as "coconut milk" for dairy.
+type matcher struct {
+	match     *regexp.Regexp
+	exception *regexp.Regexp
+}
+
+func newMatcher(terms, except []string) *matcher {
+	m := &matcher{
+		match: regexp.MustCompile(termsPattern(terms, goBoundary)),
+	}
+	if len(except) > 0 {
+		m.exception = regexp.MustCompile(termsPattern(except, goBoundary))
+	}
+	return m
+}
+
+func (m *matcher) matches(name string) bool {
+	name = strings.ToLower(name)
+	if !m.match.MatchString(name) {
+		return false
+	}
+	return m.exception == nil || !m.exception.MatchString(name)
+}
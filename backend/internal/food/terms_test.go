@@ -0,0 +1,29 @@
+package food
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTermPattern(t *testing.T) {
+	tests := []struct {
+		term  string
+		name  string
+		match bool
+	}{
+		{"cherry", "fresh cherries", true},
+		{"cherry", "cherry tomatoes", true},
+		{"tomato", "canned tomatoes", true},
+		{"egg", "eggs", true},
+		{"egg", "eggplant", false},
+		{"salt", "salted butter", false},
+		{"green bean", "green-beans", true},
+	}
+
+	for _, tt := range tests {
+		pattern := regexp.MustCompile(termPattern(tt.term, goBoundary))
+		if got := pattern.MatchString(tt.name); got != tt.match {
+			t.Errorf("term %q matching %q = %v, want %v", tt.term, tt.name, got, tt.match)
+		}
+	}
+}
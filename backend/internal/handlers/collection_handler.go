@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"yummio-backend/internal/exporter"
 	"yummio-backend/internal/middleware"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/services"
@@ -319,4 +321,70 @@ func (h *CollectionHandler) RemoveRecipeFromCollection(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Recipe removed from collection"})
-}
\ No newline at end of file
+}
+
+// PrintCollection godoc
+// @Summary Print collection
+// @Description Render a collection as one printable booklet, as HTML or PDF, with a cover page and table of contents
+// @Tags collections
+// @Produce html
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "Collection ID"
+// @Param format query string false "Output format (html/pdf)" default(html)
+// @Param servings query int false "Scale each recipe to this number of servings"
+// @Param system query string false "Convert ingredient units (metric/imperial)"
+// @Param columns query int false "Set 2 for a compact layout with ingredients beside the instructions" default(1)
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /collections/{id}/print [get]
+func (h *CollectionHandler) PrintCollection(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	collectionID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	var query models.PrintQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, err := exporter.ParsePrintFormat(query.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := h.collectionService.PrintCollection(userID, collectionID, &query)
+	if err != nil {
+		switch err.Error() {
+		case "unauthorized to access this collection":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "collection has too many recipes to print":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, collectionID, format.Extension()))
+	c.Data(http.StatusOK, format.ContentType(), content)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"yummio-backend/internal/middleware"
+	"yummio-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ImportHandler struct {
+	importService  services.ImportService
+	maxArchiveSize int64
+}
+
+func NewImportHandler(importService services.ImportService, maxArchiveSize int64) *ImportHandler {
+	return &ImportHandler{
+		importService:  importService,
+		maxArchiveSize: maxArchiveSize,
+	}
+}
+
+// ImportArchive godoc
+// @Summary Import recipe archive
+// @Description Import recipes exported from Paprika (.paprikarecipes), Mealie (zip or JSON export) or a zip of Cooklang files. The import runs in the background; poll the returned job for per-recipe results. Recipes the user already has are skipped
+// @Tags recipes
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Recipe archive"
+// @Success 202 {object} models.ImportJob
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{}
+// @Router /recipes/import/archive [post]
+func (h *ImportHandler) ImportArchive(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxArchiveSize+1<<20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No archive file provided"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxArchiveSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Archive too large"})
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read archive"})
+		return
+	}
+
+	job, err := h.importService.StartArchiveImport(userID, header.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImportJobs godoc
+// @Summary Get import jobs
+// @Description Get the current user's recent archive imports, without per-recipe results
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.ImportJob
+// @Failure 401 {object} map[string]interface{}
+// @Router /recipes/import/jobs [get]
+func (h *ImportHandler) GetImportJobs(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobs, err := h.importService.GetJobs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetImportJob godoc
+// @Summary Get import job
+// @Description Get the progress of an archive import and the outcome of every recipe
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Import job ID"
+// @Success 200 {object} models.ImportJob
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/import/jobs/{id} [get]
+func (h *ImportHandler) GetImportJob(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import job ID"})
+		return
+	}
+
+	job, err := h.importService.GetJob(userID, jobID)
+	if err != nil {
+		if err.Error() == "unauthorized to access this import job" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
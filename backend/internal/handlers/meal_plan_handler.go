@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"yummio-backend/internal/middleware"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MealPlanHandler struct {
+	mealPlanService services.MealPlanService
+	validator       *validator.Validate
+}
+
+func NewMealPlanHandler(mealPlanService services.MealPlanService) *MealPlanHandler {
+	return &MealPlanHandler{
+		mealPlanService: mealPlanService,
+		validator:       validator.New(),
+	}
+}
+
+// GetMealPlan godoc
+// @Summary Get meal plan
+// @Description Get the current user's planned meals for a date range (defaults to the current week)
+// @Tags meal-plans
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD)"
+// @Success 200 {object} models.MealPlan
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /meal-plans [get]
+func (h *MealPlanHandler) GetMealPlan(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	plan, err := h.mealPlanService.GetMealPlan(userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		if err.Error() == "invalid date" || err.Error() == "invalid date range" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// CreateEntry godoc
+// @Summary Plan a meal
+// @Description Add a recipe to the meal plan on a given day and meal slot
+// @Tags meal-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MealPlanEntryCreateRequest true "Meal plan entry data"
+// @Success 201 {object} models.MealPlanEntry
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /meal-plans/entries [post]
+func (h *MealPlanHandler) CreateEntry(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MealPlanEntryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.mealPlanService.CreateEntry(userID, &req)
+	if err != nil {
+		if err.Error() == "unauthorized to access this recipe" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateEntry godoc
+// @Summary Update planned meal
+// @Description Update a meal plan entry
+// @Tags meal-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Meal plan entry ID"
+// @Param request body models.MealPlanEntryUpdateRequest true "Meal plan entry data"
+// @Success 200 {object} models.MealPlanEntry
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /meal-plans/entries/{id} [put]
+func (h *MealPlanHandler) UpdateEntry(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	entryID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal plan entry ID"})
+		return
+	}
+
+	var req models.MealPlanEntryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.mealPlanService.UpdateEntry(userID, entryID, &req)
+	if err != nil {
+		if err.Error() == "unauthorized to modify this meal plan entry" || err.Error() == "unauthorized to access this recipe" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteEntry godoc
+// @Summary Remove planned meal
+// @Description Remove a meal plan entry
+// @Tags meal-plans
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Meal plan entry ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /meal-plans/entries/{id} [delete]
+func (h *MealPlanHandler) DeleteEntry(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	entryID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal plan entry ID"})
+		return
+	}
+
+	if err := h.mealPlanService.DeleteEntry(userID, entryID); err != nil {
+		if err.Error() == "unauthorized to modify this meal plan entry" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Meal plan entry deleted successfully"})
+}
+
+// MoveEntry godoc
+// @Summary Move planned meal
+// @Description Move a meal plan entry to another day and optionally another meal slot
+// @Tags meal-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Meal plan entry ID"
+// @Param request body models.MealPlanEntryMoveRequest true "Target day and slot"
+// @Success 200 {object} models.MealPlanEntry
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /meal-plans/entries/{id}/move [post]
+func (h *MealPlanHandler) MoveEntry(c *gin.Context) {
+	h.moveOrCopyEntry(c, h.mealPlanService.MoveEntry, http.StatusOK)
+}
+
+// CopyEntry godoc
+// @Summary Copy planned meal
+// @Description Copy a meal plan entry to another day and optionally another meal slot
+// @Tags meal-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Meal plan entry ID"
+// @Param request body models.MealPlanEntryMoveRequest true "Target day and slot"
+// @Success 201 {object} models.MealPlanEntry
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /meal-plans/entries/{id}/copy [post]
+func (h *MealPlanHandler) CopyEntry(c *gin.Context) {
+	h.moveOrCopyEntry(c, h.mealPlanService.CopyEntry, http.StatusCreated)
+}
+
+func (h *MealPlanHandler) moveOrCopyEntry(c *gin.Context, action func(userID, entryID uuid.UUID, req *models.MealPlanEntryMoveRequest) (*models.MealPlanEntry, error), status int) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	entryID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal plan entry ID"})
+		return
+	}
+
+	var req models.MealPlanEntryMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := action(userID, entryID, &req)
+	if err != nil {
+		if err.Error() == "unauthorized to modify this meal plan entry" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(status, entry)
+}
+
+// RepeatWeek godoc
+// @Summary Repeat a week
+// @Description Copy every meal planned in the week starting at from_week to the week starting at to_week
+// @Tags meal-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MealPlanRepeatWeekRequest true "Source and target weeks"
+// @Success 201 {object} models.MealPlan
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /meal-plans/repeat-week [post]
+func (h *MealPlanHandler) RepeatWeek(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MealPlanRepeatWeekRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.mealPlanService.RepeatWeek(userID, &req)
+	if err != nil {
+		if err.Error() == "invalid date" || err.Error() == "cannot repeat a week onto itself" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// GenerateShoppingList godoc
+// @Summary Create shopping list from meal plan
+// @Description Create a shopping list from every meal planned in a date range, scaled to the planned servings and skipping pantry staples
+// @Tags meal-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD)"
+// @Param request body models.MealPlanShoppingListRequest false "Shopping list options"
+// @Success 201 {object} models.ShoppingList
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /meal-plans/shopping-list [post]
+func (h *MealPlanHandler) GenerateShoppingList(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MealPlanShoppingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.mealPlanService.GenerateShoppingList(userID, c.Query("from"), c.Query("to"), &req)
+	if err != nil {
+		if err.Error() == "invalid date" || err.Error() == "invalid date range" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// CreateFeedToken godoc
+// @Summary Create calendar feed token
+// @Description Issue a token for subscribing to the meal plan as an iCalendar feed. Any previous token is revoked.
+// @Tags meal-plans
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.MealPlanFeedResponse
+// @Failure 401 {object} map[string]interface{}
+// @Router /meal-plans/feed-token [post]
+func (h *MealPlanHandler) CreateFeedToken(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	feed, err := h.mealPlanService.CreateFeedToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, feed)
+}
+
+// RevokeFeedToken godoc
+// @Summary Revoke calendar feed token
+// @Description Revoke the meal plan calendar feed token so subscribed calendars stop updating
+// @Tags meal-plans
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /meal-plans/feed-token [delete]
+func (h *MealPlanHandler) RevokeFeedToken(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.mealPlanService.RevokeFeedToken(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feed token revoked successfully"})
+}
+
+// GetFeed godoc
+// @Summary Meal plan calendar feed
+// @Description Get the meal plan as an iCalendar feed for calendar subscriptions
+// @Tags meal-plans
+// @Produce text/calendar
+// @Param token query string true "Feed token"
+// @Success 200 {string} string
+// @Failure 401 {object} map[string]interface{}
+// @Router /meal-plans/feed.ics [get]
+func (h *MealPlanHandler) GetFeed(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid feed token"})
+		return
+	}
+
+	feed, err := h.mealPlanService.GetFeed(token)
+	if err != nil {
+		if err.Error() == "invalid feed token" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid feed token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+}
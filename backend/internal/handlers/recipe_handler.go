@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"yummio-backend/internal/exporter"
+	"yummio-backend/internal/food"
 	"yummio-backend/internal/middleware"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/services"
+	"yummio-backend/internal/units"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -36,6 +41,9 @@ func NewRecipeHandler(recipeService services.RecipeService) *RecipeHandler {
 // @Param type query string false "Recipe type"
 // @Param sort_by query string false "Sort by field"
 // @Param sort_order query string false "Sort order (asc/desc)"
+// @Param exclude_ingredients query []string false "Leave out recipes containing these ingredients"
+// @Param allergens query []string false "Leave out recipes containing these allergens (celery, dairy, egg, fish, gluten, mustard, nuts, peanuts, sesame, shellfish, soy)"
+// @Param diets query []string false "Only recipes with these dietary labels (vegan, vegetarian, gluten-free, keto)"
 // @Success 200 {object} map[string]interface{}
 // @Router /recipes [get]
 func (h *RecipeHandler) GetRecipes(c *gin.Context) {
@@ -45,6 +53,11 @@ func (h *RecipeHandler) GetRecipes(c *gin.Context) {
 		return
 	}
 
+	if err := normalizeRecipeQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	recipes, total, err := h.recipeService.GetRecipes(&query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -61,33 +74,185 @@ func (h *RecipeHandler) GetRecipes(c *gin.Context) {
 
 // GetRecipe godoc
 // @Summary Get recipe by ID
-// @Description Get a specific recipe by its ID
+// @Description Get a specific recipe by its ID. Drafts, archived and private recipes are only returned to their owner. Ingredients that are other recipes include them as sub_recipe
 // @Tags recipes
 // @Produce json
 // @Param id path string true "Recipe ID"
+// @Param servings query int false "Scale ingredients to this number of servings"
+// @Param system query string false "Convert ingredient units (metric/imperial)"
 // @Success 200 {object} models.Recipe
+// @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Router /recipes/{id} [get]
 func (h *RecipeHandler) GetRecipe(c *gin.Context) {
+	recipe, ok := h.loadRecipe(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// ExportRecipe godoc
+// @Summary Export recipe
+// @Description Render a recipe as Markdown, plain text, schema.org JSON-LD or Cooklang
+// @Tags recipes
+// @Produce plain
+// @Param id path string true "Recipe ID"
+// @Param format query string true "Export format (markdown/text/jsonld/cooklang)"
+// @Param servings query int false "Scale ingredients to this number of servings"
+// @Param system query string false "Convert ingredient units (metric/imperial)"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/export [get]
+func (h *RecipeHandler) ExportRecipe(c *gin.Context) {
+	format, err := exporter.ParseFormat(c.DefaultQuery("format", string(exporter.Markdown)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, ok := h.loadRecipe(c)
+	if !ok {
+		return
+	}
+
+	content, err := h.recipeService.ExportRecipe(recipe, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, recipe.ID, format.Extension()))
+	c.Data(http.StatusOK, format.ContentType(), content)
+}
+
+// PrintRecipe godoc
+// @Summary Print recipe
+// @Description Render a recipe as a printable HTML page or PDF with its image, ingredients, instructions and nutrition
+// @Tags recipes
+// @Produce html
+// @Produce application/pdf
+// @Param id path string true "Recipe ID"
+// @Param format query string false "Output format (html/pdf)" default(html)
+// @Param servings query int false "Scale ingredients to this number of servings"
+// @Param system query string false "Convert ingredient units (metric/imperial)"
+// @Param columns query int false "Set 2 for a compact layout with ingredients beside the instructions" default(1)
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/print [get]
+func (h *RecipeHandler) PrintRecipe(c *gin.Context) {
+	var query models.PrintQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, err := exporter.ParsePrintFormat(query.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, ok := h.loadRecipe(c)
+	if !ok {
+		return
+	}
+
+	book := &exporter.Booklet{Recipes: []*models.Recipe{recipe}}
+	content, err := h.recipeService.PrintRecipes(book, format, exporter.PrintOptions{TwoColumn: query.Columns == 2})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, recipe.ID, format.Extension()))
+	c.Data(http.StatusOK, format.ContentType(), content)
+}
+
+// GetCookMode godoc
+// @Summary Get recipe cook mode
+// @Description Lay a recipe out for a step-by-step kitchen screen: each step with its timer and the ingredients it uses, and the ingredients grouped by the first step that needs them. Steps without linked ingredients list the ones their text mentions
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param servings query int false "Scale ingredients to this number of servings"
+// @Param system query string false "Convert ingredient units (metric/imperial)"
+// @Success 200 {object} models.CookMode
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/cook-mode [get]
+func (h *RecipeHandler) GetCookMode(c *gin.Context) {
+	recipe, ok := h.loadRecipe(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.recipeService.CookMode(recipe))
+}
+
+// loadRecipe loads the recipe of the :id parameter, scaled and converted as
+// asked by the servings and system query parameters. Recipes that aren't
+// listed are only served to their owner. It writes the error response and
+// returns false when the recipe can't be served.
+func (h *RecipeHandler) loadRecipe(c *gin.Context) (*models.Recipe, bool) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
-		return
+		return nil, false
 	}
 
 	recipe, err := h.recipeService.GetRecipe(id)
-	if err != nil {
+	if err != nil || !canViewRecipe(c, recipe) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, recipe)
+	if servingsStr := c.Query("servings"); servingsStr != "" {
+		servings, convErr := strconv.Atoi(servingsStr)
+		if convErr != nil || servings < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid servings"})
+			return nil, false
+		}
+		if err := h.recipeService.ScaleRecipe(recipe, servings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+	}
+
+	if systemStr := c.Query("system"); systemStr != "" {
+		system, err := units.ParseSystem(systemStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		h.recipeService.ConvertRecipe(recipe, system)
+	}
+
+	return recipe, true
+}
+
+// canViewRecipe reports whether the caller may see a recipe: listed ones
+// are public, drafts, archived and private recipes only their owner's.
+func canViewRecipe(c *gin.Context, recipe *models.Recipe) bool {
+	if recipe.IsListed() {
+		return true
+	}
+	userID, exists := middleware.GetUserID(c)
+	return exists && userID == recipe.UserID
 }
 
 // CreateRecipe godoc
 // @Summary Create recipe
-// @Description Create a new recipe
+// @Description Create a new recipe. An ingredient with a sub_recipe_id uses another recipe, such as a sauce or dough, in the given amount
 // @Tags recipes
 // @Accept json
 // @Produce json
@@ -117,6 +282,10 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 
 	recipe, err := h.recipeService.CreateRecipe(userID, &req)
 	if err != nil {
+		if isInvalidRecipeError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -170,6 +339,10 @@ func (h *RecipeHandler) UpdateRecipe(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if isInvalidRecipeError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -217,10 +390,15 @@ func (h *RecipeHandler) DeleteRecipe(c *gin.Context) {
 
 // SearchRecipes godoc
 // @Summary Search recipes
-// @Description Search recipes by title and description
+// @Description Full-text search over recipe titles, descriptions, tags and ingredients, ranked by relevance. Use "quotes" for phrases and a trailing * for prefixes. Results include highlighted snippets.
 // @Tags recipes
 // @Produce json
 // @Param q query string true "Search query"
+// @Param difficulty query string false "Recipe difficulty"
+// @Param type query string false "Recipe type"
+// @Param exclude_ingredients query []string false "Leave out recipes containing these ingredients"
+// @Param allergens query []string false "Leave out recipes containing these allergens"
+// @Param diets query []string false "Only recipes with these dietary labels"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
 // @Success 200 {object} map[string]interface{}
@@ -232,6 +410,14 @@ func (h *RecipeHandler) SearchRecipes(c *gin.Context) {
 		return
 	}
 
+	if err := normalizeRecipeQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q := c.Query("q"); q != "" {
+		query.Search = &q
+	}
+
 	recipes, total, err := h.recipeService.SearchRecipes(&query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -246,13 +432,41 @@ func (h *RecipeHandler) SearchRecipes(c *gin.Context) {
 	})
 }
 
+// Autocomplete godoc
+// @Summary Autocomplete search
+// @Description Suggest recipe titles, ingredient names and tags for a partial, possibly misspelt, search term
+// @Tags recipes
+// @Produce json
+// @Param q query string true "Search term"
+// @Param limit query int false "Suggestions per group" default(5)
+// @Success 200 {object} models.AutocompleteResponse
+// @Router /recipes/autocomplete [get]
+func (h *RecipeHandler) Autocomplete(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > 20 {
+		limit = 5
+	}
+
+	suggestions, err := h.recipeService.Autocomplete(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
 // GetFeaturedRecipes godoc
 // @Summary Get featured recipes
 // @Description Get featured recipes with high ratings
 // @Tags recipes
 // @Produce json
 // @Param limit query int false "Number of recipes to return" default(10)
+// @Param exclude_ingredients query []string false "Leave out recipes containing these ingredients"
+// @Param allergens query []string false "Leave out recipes containing these allergens"
+// @Param diets query []string false "Only recipes with these dietary labels"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Router /recipes/featured [get]
 func (h *RecipeHandler) GetFeaturedRecipes(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "10")
@@ -261,7 +475,17 @@ func (h *RecipeHandler) GetFeaturedRecipes(c *gin.Context) {
 		limit = 10
 	}
 
-	recipes, err := h.recipeService.GetFeaturedRecipes(limit)
+	query := models.RecipeQuery{
+		ExcludeIngredients: c.QueryArray("exclude_ingredients"),
+		Allergens:          c.QueryArray("allergens"),
+		Diets:              c.QueryArray("diets"),
+	}
+	if err := normalizeRecipeQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipes, err := h.recipeService.GetFeaturedRecipes(limit, &query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -278,7 +502,9 @@ func (h *RecipeHandler) GetFeaturedRecipes(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Only recipes with this status (draft/published/archived)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Router /recipes/my-recipes [get]
 func (h *RecipeHandler) GetMyRecipes(c *gin.Context) {
@@ -294,6 +520,11 @@ func (h *RecipeHandler) GetMyRecipes(c *gin.Context) {
 		return
 	}
 
+	if err := normalizeRecipeQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	recipes, total, err := h.recipeService.GetMyRecipes(userID, &query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -374,6 +605,155 @@ func (h *RecipeHandler) UnfavoriteRecipe(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Recipe removed from favorites"})
 }
 
+// FindByIngredients godoc
+// @Summary Find recipes by available ingredients
+// @Description Find public recipes that can be cooked with the given ingredients, ranked by how many of the recipe's ingredients are covered
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RecipesByIngredientsRequest true "Available ingredients and options"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /recipes/by-ingredients [post]
+func (h *RecipeHandler) FindByIngredients(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.RecipesByIngredientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	recipes, total, err := h.recipeService.FindByIngredients(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recipes": recipes,
+		"total":   total,
+		"page":    req.Page,
+		"limit":   req.Limit,
+	})
+}
+
+// ImportRecipe godoc
+// @Summary Import recipe from a web page
+// @Description Extract a schema.org Recipe (JSON-LD or microdata) from a URL or pasted HTML. Returns a draft preview unless confirm is set, in which case the draft, or the edited recipe sent along, is saved
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RecipeImportRequest true "Page to import"
+// @Success 200 {object} models.RecipeImportPreview
+// @Success 201 {object} models.Recipe
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /recipes/import [post]
+func (h *RecipeHandler) ImportRecipe(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.RecipeImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft := req.Recipe
+	if !req.Confirm || draft == nil {
+		preview, err := h.recipeService.PreviewImport(&req)
+		if err != nil {
+			switch err.Error() {
+			case "invalid recipe URL":
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case "no recipe found on page":
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case "could not fetch recipe page":
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		if !req.Confirm {
+			c.JSON(http.StatusOK, preview)
+			return
+		}
+		draft = &preview.Recipe
+	}
+
+	if err := h.validator.Struct(draft); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, err := h.recipeService.CreateRecipe(userID, draft)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recipe)
+}
+
+// ParseIngredients godoc
+// @Summary Parse ingredient lines
+// @Description Parse free-text ingredient lines such as "1 1/2 cups (190 g) all-purpose flour, sifted" into amount, unit, name and notes
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Param request body models.ParseIngredientsRequest true "Ingredient lines"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /ingredients/parse [post]
+func (h *RecipeHandler) ParseIngredients(c *gin.Context) {
+	var req models.ParseIngredientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ingredients": h.recipeService.ParseIngredients(req.Lines),
+	})
+}
+
 // GetFavorites godoc
 // @Summary Get user's favorite recipes
 // @Description Get current user's favorite recipes
@@ -398,6 +778,11 @@ func (h *RecipeHandler) GetFavorites(c *gin.Context) {
 		return
 	}
 
+	if err := normalizeRecipeQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	recipes, total, err := h.recipeService.GetFavorites(userID, &query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -456,4 +841,327 @@ func (h *RecipeHandler) RateRecipe(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Recipe rated successfully"})
-}
\ No newline at end of file
+}
+
+// normalizeRecipeQuery accepts comma separated ingredient, allergen and diet
+// lists and rejects unknown allergens and diets, so a typo never lets unsafe
+// recipes through.
+func normalizeRecipeQuery(query *models.RecipeQuery) error {
+	query.ExcludeIngredients = splitQueryList(query.ExcludeIngredients)
+
+	allergens := splitQueryList(query.Allergens)
+	for i, name := range allergens {
+		class, ok := food.NormalizeAllergen(name)
+		if !ok {
+			return fmt.Errorf("unknown allergen: %s", name)
+		}
+		allergens[i] = class
+	}
+	query.Allergens = allergens
+
+	diets := splitQueryList(query.Diets)
+	for i, name := range diets {
+		label, ok := food.NormalizeDietLabel(name)
+		if !ok {
+			return fmt.Errorf("unknown diet: %s", name)
+		}
+		diets[i] = label
+	}
+	query.Diets = diets
+
+	if query.Status != nil && *query.Status != "" {
+		switch *query.Status {
+		case models.RecipeStatusDraft, models.RecipeStatusPublished, models.RecipeStatusArchived:
+		default:
+			return fmt.Errorf("unknown status: %s", *query.Status)
+		}
+	}
+
+	return nil
+}
+
+// isInvalidRecipeError reports whether a recipe was rejected for its publish
+// time, its sub-recipes or the ingredients its steps use.
+func isInvalidRecipeError(err error) bool {
+	switch err.Error() {
+	case "publish_at can only be set on drafts",
+		"publish_at must be in the future",
+		"a recipe can't be an ingredient of itself",
+		"sub-recipe not found",
+		"sub-recipe already uses this recipe",
+		"sub-recipes are nested too deeply",
+		"step ingredient has no position",
+		"step uses an unknown ingredient":
+		return true
+	}
+	return false
+}
+
+func splitQueryList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// ForkRecipe godoc
+// @Summary Fork recipe
+// @Description Copy a public recipe into the current user's account as a private, editable recipe crediting the original
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Success 201 {object} models.Recipe
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/fork [post]
+func (h *RecipeHandler) ForkRecipe(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	recipeID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return
+	}
+
+	recipe, err := h.recipeService.ForkRecipe(userID, recipeID)
+	if err != nil {
+		if err.Error() == "unauthorized to fork this recipe" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recipe)
+}
+
+// GetRecipeForks godoc
+// @Summary Get recipe forks
+// @Description Get paginated list of the public forks of a recipe
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /recipes/{id}/forks [get]
+func (h *RecipeHandler) GetRecipeForks(c *gin.Context) {
+	idStr := c.Param("id")
+	recipeID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return
+	}
+
+	var query models.RecipeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := normalizeRecipeQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipes, total, err := h.recipeService.GetForks(recipeID, &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recipes": recipes,
+		"total":   total,
+		"page":    query.Page,
+		"limit":   query.Limit,
+	})
+}
+
+// GetRecipeRevisions godoc
+// @Summary Get recipe revisions
+// @Description List the saved revisions of one of the current user's recipes, newest first
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/revisions [get]
+func (h *RecipeHandler) GetRecipeRevisions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	recipeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return
+	}
+
+	revisions, err := h.recipeService.GetRevisions(userID, recipeID)
+	if err != nil {
+		revisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// GetRecipeRevision godoc
+// @Summary Get recipe revision
+// @Description Get a revision of a recipe with its full snapshot
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.RecipeRevision
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/revisions/{rev} [get]
+func (h *RecipeHandler) GetRecipeRevision(c *gin.Context) {
+	userID, recipeID, number, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	revision, err := h.recipeService.GetRevision(userID, recipeID, number)
+	if err != nil {
+		revisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRecipeRevision godoc
+// @Summary Diff recipe revisions
+// @Description List the fields, ingredients, steps and tags that changed in a revision compared to an earlier one
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Param rev path int true "Revision number"
+// @Param against query int false "Revision to compare with, 0 for an empty recipe (defaults to the previous revision)"
+// @Success 200 {object} models.RecipeDiff
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/revisions/{rev}/diff [get]
+func (h *RecipeHandler) DiffRecipeRevision(c *gin.Context) {
+	userID, recipeID, number, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	against := number - 1
+	if againstStr := c.Query("against"); againstStr != "" {
+		var err error
+		if against, err = strconv.Atoi(againstStr); err != nil || against < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+			return
+		}
+	}
+
+	diff, err := h.recipeService.DiffRevisions(userID, recipeID, against, number)
+	if err != nil {
+		revisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreRecipeRevision godoc
+// @Summary Restore recipe revision
+// @Description Save a past revision as the recipe's current state, recorded as a new revision
+// @Tags recipes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} models.Recipe
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/revisions/{rev}/restore [post]
+func (h *RecipeHandler) RestoreRecipeRevision(c *gin.Context) {
+	userID, recipeID, number, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	recipe, err := h.recipeService.RestoreRevision(userID, recipeID, number)
+	if err != nil {
+		revisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// revisionParams reads the user and the :id and :rev parameters of the
+// revision endpoints. It writes the error response and returns false when
+// one is missing or invalid.
+func revisionParams(c *gin.Context) (uuid.UUID, uuid.UUID, int, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+
+	recipeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+
+	return userID, recipeID, number, true
+}
+
+func revisionError(c *gin.Context, err error) {
+	if isInvalidRecipeError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err.Error() {
+	case "unauthorized to access this recipe", "unauthorized to update this recipe":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "revision not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+	}
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"yummio-backend/internal/middleware"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type ShareLinkHandler struct {
+	shareLinkService services.ShareLinkService
+	validator        *validator.Validate
+}
+
+func NewShareLinkHandler(shareLinkService services.ShareLinkService) *ShareLinkHandler {
+	return &ShareLinkHandler{
+		shareLinkService: shareLinkService,
+		validator:        validator.New(),
+	}
+}
+
+// CreateRecipeShareLink godoc
+// @Summary Create recipe share link
+// @Description Create an unlisted link that lets anyone open the recipe read-only, even when it is private. The link is only returned now
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Param request body models.ShareLinkCreateRequest false "Link options"
+// @Success 201 {object} models.ShareLink
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/share-links [post]
+func (h *ShareLinkHandler) CreateRecipeShareLink(c *gin.Context) {
+	h.createLink(c, "recipe", h.shareLinkService.CreateRecipeLink)
+}
+
+// CreateCollectionShareLink godoc
+// @Summary Create collection share link
+// @Description Create an unlisted link that lets anyone open the collection read-only, even when it is private. The link is only returned now
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Collection ID"
+// @Param request body models.ShareLinkCreateRequest false "Link options"
+// @Success 201 {object} models.ShareLink
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /collections/{id}/share-links [post]
+func (h *ShareLinkHandler) CreateCollectionShareLink(c *gin.Context) {
+	h.createLink(c, "collection", h.shareLinkService.CreateCollectionLink)
+}
+
+// GetRecipeShareLinks godoc
+// @Summary Get recipe share links
+// @Description List the recipe's share links that are neither revoked nor expired, with their view counts
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recipe ID"
+// @Success 200 {array} models.ShareLink
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /recipes/{id}/share-links [get]
+func (h *ShareLinkHandler) GetRecipeShareLinks(c *gin.Context) {
+	h.getLinks(c, "recipe", h.shareLinkService.GetRecipeLinks)
+}
+
+// GetCollectionShareLinks godoc
+// @Summary Get collection share links
+// @Description List the collection's share links that are neither revoked nor expired, with their view counts
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Collection ID"
+// @Success 200 {array} models.ShareLink
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /collections/{id}/share-links [get]
+func (h *ShareLinkHandler) GetCollectionShareLinks(c *gin.Context) {
+	h.getLinks(c, "collection", h.shareLinkService.GetCollectionLinks)
+}
+
+// createLink creates a share link for the item named by the id parameter.
+func (h *ShareLinkHandler) createLink(c *gin.Context, item string, create func(userID, id uuid.UUID, req *models.ShareLinkCreateRequest) (*models.ShareLink, error)) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + item + " ID"})
+		return
+	}
+
+	var req models.ShareLinkCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := create(userID, id, &req)
+	if err != nil {
+		shareLinkError(c, item, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// getLinks lists the active share links of the item named by the id
+// parameter.
+func (h *ShareLinkHandler) getLinks(c *gin.Context, item string, get func(userID, id uuid.UUID) ([]models.ShareLink, error)) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + item + " ID"})
+		return
+	}
+
+	links, err := get(userID, id)
+	if err != nil {
+		shareLinkError(c, item, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func shareLinkError(c *gin.Context, item string, err error) {
+	switch {
+	case err.Error() == "expires_at must be in the future":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "unauthorized to share this "+item:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == item+" not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": strings.ToUpper(item[:1]) + item[1:] + " not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetShareLinks godoc
+// @Summary Get share links
+// @Description List all of the current user's share links that are neither revoked nor expired, with their view counts
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.ShareLink
+// @Failure 401 {object} map[string]interface{}
+// @Router /share-links [get]
+func (h *ShareLinkHandler) GetShareLinks(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	links, err := h.shareLinkService.GetLinks(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink godoc
+// @Summary Revoke share link
+// @Description Revoke a share link so it no longer opens its recipe or collection
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Share link ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /share-links/{id} [delete]
+func (h *ShareLinkHandler) RevokeShareLink(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	linkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link ID"})
+		return
+	}
+
+	if err := h.shareLinkService.RevokeLink(userID, linkID); err != nil {
+		switch err.Error() {
+		case "unauthorized to revoke this share link":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "share link not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked successfully"})
+}
+
+// GetSharedItem godoc
+// @Summary Open share link
+// @Description Get the recipe or collection a share link was made for. No account is needed
+// @Tags share-links
+// @Produce json
+// @Param token path string true "Share link token"
+// @Success 200 {object} models.SharedItem
+// @Failure 404 {object} map[string]interface{}
+// @Router /shared/{token} [get]
+func (h *ShareLinkHandler) GetSharedItem(c *gin.Context) {
+	item, err := h.shareLinkService.OpenLink(c.Param("token"))
+	if err != nil {
+		if err.Error() == "share link not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found or expired"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, item)
+}
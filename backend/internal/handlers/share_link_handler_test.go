@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+	"yummio-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type fakeRecipeRepository struct {
+	repositories.RecipeRepository
+	recipes map[uuid.UUID]*models.Recipe
+}
+
+func (r *fakeRecipeRepository) GetByID(id uuid.UUID) (*models.Recipe, error) {
+	recipe, ok := r.recipes[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *recipe
+	return &copied, nil
+}
+
+type fakeShareLinkRepository struct {
+	repositories.ShareLinkRepository
+	links map[string]*models.ShareLink
+}
+
+func (r *fakeShareLinkRepository) GetByTokenHash(hash string) (*models.ShareLink, error) {
+	link, ok := r.links[hash]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return link, nil
+}
+
+func (r *fakeShareLinkRepository) Create(link *models.ShareLink) error {
+	link.ID = uuid.New()
+	r.links[link.TokenHash] = link
+	return nil
+}
+
+func (r *fakeShareLinkRepository) RecordView(id uuid.UUID) error {
+	return nil
+}
+
+func TestPrivateRecipeNeedsShareLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner, stranger := uuid.New(), uuid.New()
+	private := &models.Recipe{ID: uuid.New(), UserID: owner, Title: "Secret Stew", IsPublic: false, Status: models.RecipeStatusPublished}
+	draft := &models.Recipe{ID: uuid.New(), UserID: owner, Title: "Spring Risotto", IsPublic: true, Status: models.RecipeStatusDraft}
+	public := &models.Recipe{ID: uuid.New(), UserID: owner, Title: "Tomato Soup", IsPublic: true, Status: models.RecipeStatusPublished}
+	recipeRepo := &fakeRecipeRepository{recipes: map[uuid.UUID]*models.Recipe{
+		private.ID: private,
+		draft.ID:   draft,
+		public.ID:  public,
+	}}
+
+	hash := func(token string) string {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+	revokedAt := time.Now().Add(-time.Hour)
+	shareLinkRepo := &fakeShareLinkRepository{links: map[string]*models.ShareLink{
+		hash("valid"):   {ID: uuid.New(), UserID: owner, ResourceType: models.ShareRecipe, ResourceID: private.ID},
+		hash("revoked"): {ID: uuid.New(), UserID: owner, ResourceType: models.ShareRecipe, ResourceID: private.ID, RevokedAt: &revokedAt},
+	}}
+
+	recipeService := services.NewRecipeService(recipeRepo, nil, nil, "http://localhost:3000")
+	shareLinkService := services.NewShareLinkService(shareLinkRepo, recipeRepo, nil, recipeService, "http://localhost:8080")
+	recipeHandler := NewRecipeHandler(recipeService)
+	shareLinkHandler := NewShareLinkHandler(shareLinkService)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID, err := uuid.Parse(c.GetHeader("X-Test-User")); err == nil {
+			c.Set("user_id", userID)
+		}
+	})
+	router.GET("/recipes/:id", recipeHandler.GetRecipe)
+	router.GET("/recipes/:id/export", recipeHandler.ExportRecipe)
+	router.GET("/recipes/:id/cook-mode", recipeHandler.GetCookMode)
+	router.GET("/shared/:token", shareLinkHandler.GetSharedItem)
+
+	tests := []struct {
+		name string
+		path string
+		user uuid.UUID
+		want int
+	}{
+		{"private recipe without a link", "/recipes/" + private.ID.String(), uuid.Nil, http.StatusNotFound},
+		{"private recipe for another user", "/recipes/" + private.ID.String(), stranger, http.StatusNotFound},
+		{"private recipe for its owner", "/recipes/" + private.ID.String(), owner, http.StatusOK},
+		{"private recipe export without a link", "/recipes/" + private.ID.String() + "/export", uuid.Nil, http.StatusNotFound},
+		{"private recipe cook mode without a link", "/recipes/" + private.ID.String() + "/cook-mode", uuid.Nil, http.StatusNotFound},
+		{"draft without a link", "/recipes/" + draft.ID.String(), uuid.Nil, http.StatusNotFound},
+		{"public recipe", "/recipes/" + public.ID.String(), uuid.Nil, http.StatusOK},
+		{"private recipe with a link", "/shared/valid", uuid.Nil, http.StatusOK},
+		{"private recipe with a revoked link", "/shared/revoked", uuid.Nil, http.StatusNotFound},
+		{"unknown link", "/shared/guess", uuid.Nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != uuid.Nil {
+				req.Header.Set("X-Test-User", tt.user.String())
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d: %s", tt.path, w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestShareLinkURLOpensItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := uuid.New()
+	private := &models.Recipe{ID: uuid.New(), UserID: owner, Title: "Secret Stew", Status: models.RecipeStatusPublished}
+	recipeRepo := &fakeRecipeRepository{recipes: map[uuid.UUID]*models.Recipe{private.ID: private}}
+	shareLinkRepo := &fakeShareLinkRepository{links: map[string]*models.ShareLink{}}
+
+	recipeService := services.NewRecipeService(recipeRepo, nil, nil, "http://localhost:8081")
+	shareLinkService := services.NewShareLinkService(shareLinkRepo, recipeRepo, nil, recipeService, "https://api.example.com/")
+	shareLinkHandler := NewShareLinkHandler(shareLinkService)
+
+	router := gin.New()
+	router.Group("/api/v1").GET("/shared/:token", shareLinkHandler.GetSharedItem)
+
+	link, err := shareLinkService.CreateRecipeLink(owner, private.ID, &models.ShareLinkCreateRequest{})
+	if err != nil {
+		t.Fatalf("CreateRecipeLink() error = %v", err)
+	}
+	path, ok := strings.CutPrefix(link.URL, "https://api.example.com")
+	if !ok {
+		t.Fatalf("url = %q, want it under the API URL", link.URL)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET %s = %d, want %d: %s", link.URL, w.Code, http.StatusOK, w.Body.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"yummio-backend/internal/middleware"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/services"
+	"yummio-backend/internal/units"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -93,6 +94,50 @@ func (h *ShoppingListHandler) CreateShoppingList(c *gin.Context) {
 	c.JSON(http.StatusCreated, list)
 }
 
+// CreateFromRecipes godoc
+// @Summary Create shopping list from recipes
+// @Description Create a shopping list from one or more recipes, merging identical ingredients
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.ShoppingListFromRecipesRequest true "Recipes and servings"
+// @Success 201 {object} models.ShoppingList
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /shopping-lists/from-recipes [post]
+func (h *ShoppingListHandler) CreateFromRecipes(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.ShoppingListFromRecipesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.shoppingListService.CreateFromRecipes(userID, &req)
+	if err != nil {
+		if err.Error() == "unauthorized to access this recipe" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
 // GetShoppingList godoc
 // @Summary Get shopping list by ID
 // @Description Get a specific shopping list by its ID
@@ -100,6 +145,7 @@ func (h *ShoppingListHandler) CreateShoppingList(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Shopping list ID"
+// @Param system query string false "Convert item units (metric/imperial)"
 // @Success 200 {object} models.ShoppingList
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
@@ -130,6 +176,15 @@ func (h *ShoppingListHandler) GetShoppingList(c *gin.Context) {
 		return
 	}
 
+	if systemStr := c.Query("system"); systemStr != "" {
+		system, err := units.ParseSystem(systemStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.shoppingListService.ConvertShoppingList(list, system)
+	}
+
 	c.JSON(http.StatusOK, list)
 }
 
@@ -380,4 +435,103 @@ func (h *ShoppingListHandler) DeleteItem(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+}
+
+// AddRecipe godoc
+// @Summary Add recipe to shopping list
+// @Description Add a recipe's ingredients to a shopping list, merging them with matching uncompleted items
+// @Tags shopping-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Shopping list ID"
+// @Param request body models.RecipeServingsRequest true "Recipe and servings"
+// @Success 200 {object} models.ShoppingList
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /shopping-lists/{id}/recipes [post]
+func (h *ShoppingListHandler) AddRecipe(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	listID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shopping list ID"})
+		return
+	}
+
+	var req models.RecipeServingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.shoppingListService.AddRecipe(userID, listID, &req)
+	if err != nil {
+		if err.Error() == "unauthorized to modify this shopping list" || err.Error() == "unauthorized to access this recipe" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// RemoveRecipe godoc
+// @Summary Remove recipe from shopping list
+// @Description Subtract a recipe's ingredients from a shopping list
+// @Tags shopping-lists
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Shopping list ID"
+// @Param recipeId path string true "Recipe ID"
+// @Success 200 {object} models.ShoppingList
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /shopping-lists/{id}/recipes/{recipeId} [delete]
+func (h *ShoppingListHandler) RemoveRecipe(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	idStr := c.Param("id")
+	listID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shopping list ID"})
+		return
+	}
+
+	recipeIDStr := c.Param("recipeId")
+	recipeID, err := uuid.Parse(recipeIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipe ID"})
+		return
+	}
+
+	list, err := h.shoppingListService.RemoveRecipe(userID, listID, recipeID)
+	if err != nil {
+		if err.Error() == "unauthorized to modify this shopping list" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
 }
\ No newline at end of file
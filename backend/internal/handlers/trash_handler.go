@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"yummio-backend/internal/middleware"
+	"yummio-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TrashHandler struct {
+	trashService services.TrashService
+}
+
+func NewTrashHandler(trashService services.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// GetTrash godoc
+// @Summary Get trash
+// @Description List the current user's deleted recipes, collections and shopping lists, most recently deleted first, with when each is purged for good
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TrashItem
+// @Failure 401 {object} map[string]interface{}
+// @Router /trash [get]
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	items, err := h.trashService.GetTrash(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreItem godoc
+// @Summary Restore item from trash
+// @Description Undo the deletion of a recipe, collection or shopping list
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Item type (recipe/collection/shopping-list)"
+// @Param id path string true "Item ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /trash/{type}/{id}/restore [post]
+func (h *TrashHandler) RestoreItem(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	if err := h.trashService.RestoreItem(userID, c.Param("type"), id); err != nil {
+		trashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully"})
+}
+
+// PurgeItem godoc
+// @Summary Purge item from trash
+// @Description Permanently delete a recipe, collection or shopping list from the trash. This can't be undone
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Item type (recipe/collection/shopping-list)"
+// @Param id path string true "Item ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /trash/{type}/{id} [delete]
+func (h *TrashHandler) PurgeItem(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	if err := h.trashService.PurgeItem(userID, c.Param("type"), id); err != nil {
+		trashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted permanently"})
+}
+
+// EmptyTrash godoc
+// @Summary Empty trash
+// @Description Permanently delete everything in the current user's trash. This can't be undone
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /trash [delete]
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	purged, err := h.trashService.EmptyTrash(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Trash emptied successfully", "purged": purged})
+}
+
+func trashError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "unknown trash type":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "unauthorized to "):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "item not found in trash":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
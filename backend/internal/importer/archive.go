@@ -0,0 +1,585 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/ingredients"
+	"yummio-backend/internal/models"
+)
+
+// ArchiveFormat names the recipe manager an archive was exported from.
+type ArchiveFormat string
+
+const (
+	Paprika         ArchiveFormat = "paprika"
+	Mealie          ArchiveFormat = "mealie"
+	CooklangArchive ArchiveFormat = "cooklang"
+)
+
+// ErrUnknownArchive is returned for files that aren't a supported export.
+var ErrUnknownArchive = errors.New("unsupported recipe archive")
+
+// ErrArchiveTooLarge is returned for archives that decompress to more than
+// they may.
+var ErrArchiveTooLarge = errors.New("archive is too large once decompressed")
+
+const (
+	// maxArchiveRecipes bounds how many recipes one archive may hold.
+	maxArchiveRecipes = 5000
+
+	// maxArchiveEntrySize bounds the uncompressed size of an archive entry,
+	// so a small zip can't expand into gigabytes.
+	maxArchiveEntrySize = 25 << 20
+
+	// maxArchiveExpansion bounds how many times its own size an archive may
+	// decompress to while its recipes are read. Images aren't counted, as
+	// they are only read one at a time when they are uploaded.
+	maxArchiveExpansion = 4
+)
+
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".webp"}
+
+// ArchiveRecipe is a recipe read from an archive. Err is set when the
+// entry couldn't be read; the rest of the archive is still imported.
+type ArchiveRecipe struct {
+	Source string // archive entry the recipe was read from
+	Recipe *models.RecipeCreateRequest
+	Image  *ArchiveImage
+	Err    error
+}
+
+// ArchiveImage is a recipe's image inside an archive. It is only read when
+// it is uploaded, so the images of a large archive are never all held in
+// memory at once.
+type ArchiveImage struct {
+	Name    string
+	file    *zip.File
+	paprika bool // the image is the photo_data of a Paprika recipe entry
+}
+
+// Read decompresses the image.
+func (i *ArchiveImage) Read() ([]byte, error) {
+	budget := &archiveBudget{remaining: 2 * maxArchiveEntrySize}
+	raw, err := budget.readZipFile(i.file)
+	if err != nil || !i.paprika {
+		return raw, err
+	}
+
+	data, err := readPaprikaEntry(raw, budget)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(text(data["photo_data"]))
+}
+
+// archiveBudget bounds the bytes decompressed while reading an archive.
+type archiveBudget struct {
+	remaining int64
+}
+
+func (b *archiveBudget) exceeded() bool {
+	return b.remaining < 0
+}
+
+func (b *archiveBudget) readZipFile(file *zip.File) ([]byte, error) {
+	if b.exceeded() {
+		return nil, ErrArchiveTooLarge
+	}
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return b.read(r)
+}
+
+func (b *archiveBudget) read(r io.Reader) ([]byte, error) {
+	if b.exceeded() {
+		return nil, ErrArchiveTooLarge
+	}
+	data, err := readLimited(r)
+	if err != nil {
+		return nil, err
+	}
+	b.remaining -= int64(len(data))
+	if b.exceeded() {
+		return nil, ErrArchiveTooLarge
+	}
+	return data, nil
+}
+
+// ReadArchive detects whether data is a Paprika export (.paprikarecipes), a
+// Mealie export (a zip of recipe folders or a JSON file) or a zip of
+// Cooklang files, and reads its recipes. maxSize is the largest archive
+// accepted; archives decompressing to more than a few times that are
+// rejected.
+func ReadArchive(data []byte, maxSize int64) (ArchiveFormat, []ArchiveRecipe, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		recipes, err := readMealieJSON("recipes.json", trimmed, nil)
+		if err != nil {
+			return "", nil, ErrUnknownArchive
+		}
+		return Mealie, recipes, nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, ErrUnknownArchive
+	}
+	budget := &archiveBudget{remaining: maxArchiveExpansion * maxSize}
+
+	var paprika, cooklang, mealie []*zip.File
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(path.Base(file.Name), ".") {
+			continue
+		}
+		files[file.Name] = file
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".paprikarecipe":
+			paprika = append(paprika, file)
+		case ".cook":
+			cooklang = append(cooklang, file)
+		case ".json":
+			mealie = append(mealie, file)
+		}
+	}
+
+	var format ArchiveFormat
+	var recipes []ArchiveRecipe
+	switch {
+	case len(paprika) > 0:
+		format, recipes = Paprika, readPaprika(paprika, budget)
+	case len(cooklang) > 0:
+		format, recipes = CooklangArchive, readCooklang(cooklang, files, budget)
+	case len(mealie) > 0:
+		format, recipes = Mealie, readMealie(mealie, files, budget)
+	}
+	if budget.exceeded() {
+		return "", nil, ErrArchiveTooLarge
+	}
+	if len(recipes) == 0 {
+		return "", nil, ErrUnknownArchive
+	}
+	if len(recipes) > maxArchiveRecipes {
+		return "", nil, fmt.Errorf("archive holds more than %d recipes", maxArchiveRecipes)
+	}
+	return format, recipes, nil
+}
+
+func readPaprika(files []*zip.File, budget *archiveBudget) []ArchiveRecipe {
+	var recipes []ArchiveRecipe
+	for _, file := range files {
+		recipe := ArchiveRecipe{Source: file.Name}
+
+		var data map[string]interface{}
+		raw, err := budget.readZipFile(file)
+		if err == nil {
+			data, err = readPaprikaEntry(raw, budget)
+		}
+		if err != nil {
+			recipe.Err = errors.New("unreadable Paprika recipe")
+			recipes = append(recipes, recipe)
+			continue
+		}
+
+		recipe.Recipe, recipe.Err = paprikaRecipe(data)
+		if text(data["photo_data"]) != "" && recipe.Err == nil {
+			recipe.Image = &ArchiveImage{Name: "photo.jpg", file: file, paprika: true}
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes
+}
+
+// readPaprikaEntry decodes a Paprika recipe entry, a gzipped JSON document.
+func readPaprikaEntry(raw []byte, budget *archiveBudget) (map[string]interface{}, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if raw, err = budget.read(gz); err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func paprikaRecipe(data map[string]interface{}) (*models.RecipeCreateRequest, error) {
+	req := &models.RecipeCreateRequest{Title: text(data["name"])}
+
+	description := strings.TrimSpace(text(data["description"]))
+	if notes := strings.TrimSpace(text(data["notes"])); notes != "" {
+		description = strings.TrimSpace(description + "\n\n" + notes)
+	}
+	applyMetadata(req, map[string]string{
+		"description": description,
+		"servings":    text(data["servings"]),
+		"prep time":   text(data["prep_time"]),
+		"cook time":   text(data["cook_time"]),
+		"total time":  text(data["total_time"]),
+		"difficulty":  text(data["difficulty"]),
+		"source":      text(data["source_url"]),
+		"image":       text(data["image_url"]),
+	})
+
+	addCategories(req, values(data["categories"]))
+
+	addIngredientLines(req, strings.Split(text(data["ingredients"]), "\n"))
+	addSteps(req, strings.Split(text(data["directions"]), "\n"))
+	return finishDraft(req)
+}
+
+func readMealie(files []*zip.File, all map[string]*zip.File, budget *archiveBudget) []ArchiveRecipe {
+	var recipes []ArchiveRecipe
+	for _, file := range files {
+		raw, err := budget.readZipFile(file)
+		if err != nil {
+			recipes = append(recipes, ArchiveRecipe{Source: file.Name, Err: errors.New("unreadable Mealie recipe")})
+			continue
+		}
+
+		// Mealie keeps a recipe's images in an images folder next to it,
+		// preferring the original over resized copies
+		dir := path.Dir(file.Name)
+		var candidates []string
+		for name := range all {
+			if strings.HasPrefix(name, path.Join(dir, "images")+"/") && isImage(name) {
+				candidates = append(candidates, name)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			iOriginal := strings.Contains(path.Base(candidates[i]), "original")
+			jOriginal := strings.Contains(path.Base(candidates[j]), "original")
+			if iOriginal != jOriginal {
+				return iOriginal
+			}
+			return candidates[i] < candidates[j]
+		})
+		var image *ArchiveImage
+		if len(candidates) > 0 {
+			image = &ArchiveImage{Name: path.Base(candidates[0]), file: all[candidates[0]]}
+		}
+
+		entries, err := readMealieJSON(file.Name, raw, image)
+		if err != nil {
+			continue // not a recipe, e.g. a database dump
+		}
+		recipes = append(recipes, entries...)
+	}
+	return recipes
+}
+
+// readMealieJSON reads a Mealie recipe or list of recipes. Documents that
+// aren't recipes are an error. image, if set, is the recipe's image.
+func readMealieJSON(source string, raw []byte, image *ArchiveImage) ([]ArchiveRecipe, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	var recipes []ArchiveRecipe
+	for i, item := range values(doc) {
+		data, ok := item.(map[string]interface{})
+		if !ok || text(data["name"]) == "" || (data["recipeIngredient"] == nil && data["recipeInstructions"] == nil) {
+			continue
+		}
+
+		recipe := ArchiveRecipe{Source: source}
+		if _, isList := doc.([]interface{}); isList {
+			recipe.Source = fmt.Sprintf("%s[%d]", source, i)
+		}
+		recipe.Recipe, recipe.Err = mealieRecipe(data)
+		if recipe.Err == nil {
+			recipe.Image = image
+		}
+		recipes = append(recipes, recipe)
+	}
+	if len(recipes) == 0 {
+		return nil, ErrUnknownArchive
+	}
+	return recipes, nil
+}
+
+func mealieRecipe(data map[string]interface{}) (*models.RecipeCreateRequest, error) {
+	req := &models.RecipeCreateRequest{Title: text(data["name"])}
+
+	servings := text(data["recipeServings"])
+	if servings == "" || servings == "0" {
+		servings = text(data["recipeYield"])
+	}
+	cookTime := text(data["cookTime"])
+	if cookTime == "" {
+		cookTime = text(data["performTime"])
+	}
+	applyMetadata(req, map[string]string{
+		"description": text(data["description"]),
+		"servings":    servings,
+		"prep time":   text(data["prepTime"]),
+		"cook time":   cookTime,
+		"total time":  text(data["totalTime"]),
+		"source":      text(data["orgURL"]),
+	})
+
+	var categories []interface{}
+	for _, field := range []string{"recipeCategory", "tags"} {
+		for _, category := range values(data[field]) {
+			if object, ok := category.(map[string]interface{}); ok {
+				category = object["name"]
+			}
+			categories = append(categories, category)
+		}
+	}
+	addCategories(req, categories)
+
+	for _, item := range values(data["recipeIngredient"]) {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			addIngredientLines(req, []string{text(item)})
+			continue
+		}
+
+		// Structured ingredients name a food; otherwise the note holds the
+		// whole line
+		food, _ := object["food"].(map[string]interface{})
+		if food == nil || text(food["name"]) == "" {
+			line := text(object["originalText"])
+			if line == "" {
+				line = text(object["note"])
+				if quantity, _ := object["quantity"].(float64); quantity > 0 {
+					line = text(quantity) + " " + line
+				}
+			}
+			addIngredientLines(req, []string{line})
+			continue
+		}
+
+		ingredient := models.IngredientCreateRequest{Name: text(food["name"])}
+		if quantity, err := strconv.ParseFloat(text(object["quantity"]), 64); err == nil && quantity > 0 {
+			ingredient.Amount = &quantity
+		}
+		if unit, ok := object["unit"].(map[string]interface{}); ok {
+			if name := text(unit["name"]); name != "" {
+				ingredient.Unit = &name
+			}
+		}
+		if note := strings.TrimSpace(text(object["note"])); note != "" {
+			ingredient.Notes = &note
+		}
+		order := len(req.Ingredients)
+		ingredient.OrderIndex = &order
+		req.Ingredients = append(req.Ingredients, ingredient)
+	}
+
+	var steps []string
+	for _, item := range values(data["recipeInstructions"]) {
+		if object, ok := item.(map[string]interface{}); ok {
+			item = object["text"]
+		}
+		steps = append(steps, text(item))
+	}
+	addSteps(req, steps)
+
+	if nutrition, ok := data["nutrition"].(map[string]interface{}); ok {
+		req.Nutrition = mapNutrition(nutrition)
+	}
+	return finishDraft(req)
+}
+
+func readCooklang(files []*zip.File, all map[string]*zip.File, budget *archiveBudget) []ArchiveRecipe {
+	var recipes []ArchiveRecipe
+	for _, file := range files {
+		recipe := ArchiveRecipe{Source: file.Name}
+		raw, err := budget.readZipFile(file)
+		if err != nil {
+			recipe.Err = errors.New("unreadable Cooklang recipe")
+			recipes = append(recipes, recipe)
+			continue
+		}
+
+		base := strings.TrimSuffix(file.Name, path.Ext(file.Name))
+		recipe.Recipe, recipe.Err = ParseCooklang(string(raw), path.Base(base))
+
+		// Cooklang tools keep a recipe's photo next to it under the same name
+		for _, ext := range imageExtensions {
+			if image, ok := all[base+ext]; ok && recipe.Err == nil {
+				recipe.Image = &ArchiveImage{Name: path.Base(image.Name), file: image}
+				break
+			}
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes
+}
+
+// applyMetadata sets the recipe details other apps store as loose text.
+func applyMetadata(req *models.RecipeCreateRequest, metadata map[string]string) {
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := strings.TrimSpace(metadata[key]); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	if description := first("description", "introduction"); description != "" {
+		req.Description = &description
+	}
+	if n := integerPattern.FindString(first("servings", "serves", "yield")); n != "" {
+		if servings, _ := strconv.Atoi(n); servings > 0 {
+			req.Servings = &servings
+		}
+	}
+
+	minutes := func(keys ...string) *int {
+		if value, ok := ParseMinutes(first(keys...)); ok && value > 0 {
+			return &value
+		}
+		return nil
+	}
+	req.PrepTime = minutes("prep time", "prep_time", "prep")
+	req.CookTime = minutes("cook time", "cook_time", "cook")
+	if total := minutes("total time", "time", "duration"); total != nil && req.CookTime == nil {
+		cook := *total
+		if req.PrepTime != nil {
+			cook -= *req.PrepTime
+		}
+		if cook > 0 {
+			req.CookTime = &cook
+		}
+	}
+
+	if difficulty := first("difficulty"); difficulty != "" {
+		req.Difficulty = &difficulty
+	}
+	if source := first("source", "source.url", "url"); source != "" {
+		req.SourceURL = &source
+	}
+	if image := first("image"); image != "" {
+		req.ImageURL = &image
+	}
+
+	var categories []interface{}
+	for _, key := range []string{"course", "category", "cuisine", "tags"} {
+		for _, tag := range strings.Split(strings.Trim(metadata[key], "[]"), ",") {
+			categories = append(categories, strings.Trim(strings.TrimSpace(tag), `"'`))
+		}
+	}
+	addCategories(req, categories)
+}
+
+// addCategories turns categories into tags, using the first that names a
+// recipe type as the type.
+func addCategories(req *models.RecipeCreateRequest, categories []interface{}) {
+	for _, category := range categories {
+		if t, ok := recipeTypes[strings.ToLower(cleanText(text(category)))]; ok && req.Type == nil {
+			req.Type = &t
+		}
+	}
+	existing := make([]interface{}, 0, len(req.Tags)+len(categories))
+	for _, tag := range req.Tags {
+		existing = append(existing, tag)
+	}
+	req.Tags = tags(append(existing, categories...))
+}
+
+// addIngredientLines parses ingredient lines, skipping blank lines and
+// section headings such as "For the sauce:".
+func addIngredientLines(req *models.RecipeCreateRequest, lines []string) {
+	for _, line := range lines {
+		line = cleanText(line)
+		if line == "" || (strings.HasSuffix(line, ":") && !strings.ContainsAny(line, "0123456789")) {
+			continue
+		}
+		ingredient := ingredients.Parse(line)
+		order := len(req.Ingredients)
+		ingredient.OrderIndex = &order
+		req.Ingredients = append(req.Ingredients, ingredient)
+	}
+}
+
+// addSteps adds instructions, one per non-blank paragraph.
+func addSteps(req *models.RecipeCreateRequest, steps []string) {
+	for _, step := range steps {
+		step = stepNumberPattern.ReplaceAllString(cleanText(step), "")
+		if step == "" {
+			continue
+		}
+		req.Instructions = append(req.Instructions, models.InstructionCreateRequest{
+			Step:        len(req.Instructions) + 1,
+			Instruction: step,
+		})
+	}
+}
+
+// finishDraft checks and tidies a recipe read from another app so it can be
+// saved as is: the title is required, and values our recipes don't accept
+// are dropped rather than failing the import.
+func finishDraft(req *models.RecipeCreateRequest) (*models.RecipeCreateRequest, error) {
+	req.Title = cleanText(req.Title)
+	if len([]rune(req.Title)) < 2 {
+		return nil, errors.New("recipe has no title")
+	}
+	if title := []rune(req.Title); len(title) > 200 {
+		req.Title = string(title[:200])
+	}
+
+	if req.Difficulty != nil {
+		difficulty := strings.ToLower(*req.Difficulty)
+		req.Difficulty = nil
+		if difficulty == "easy" || difficulty == "medium" || difficulty == "hard" {
+			req.Difficulty = &difficulty
+		}
+	}
+	if req.SourceURL != nil {
+		source := absoluteURL(*req.SourceURL, "")
+		req.SourceURL = nil
+		if source != "" && len(source) <= 2048 {
+			req.SourceURL = &source
+		}
+	}
+	if req.ImageURL != nil {
+		image := absoluteURL(*req.ImageURL, "")
+		req.ImageURL = nil
+		if image != "" {
+			req.ImageURL = &image
+		}
+	}
+	return req, nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArchiveEntrySize {
+		return nil, errors.New("archive entry too large")
+	}
+	return data, nil
+}
+
+func isImage(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, imageExt := range imageExtensions {
+		if ext == imageExt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func zipArchive(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func paprikaEntry(t *testing.T, recipe map[string]interface{}) []byte {
+	t.Helper()
+	raw, err := json.Marshal(recipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(raw)
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestReadArchiveLoadsImagesLazily(t *testing.T) {
+	photo := []byte("not really a jpeg")
+	data := zipArchive(t, map[string][]byte{
+		"Pancakes.paprikarecipe": paprikaEntry(t, map[string]interface{}{
+			"name":        "Pancakes",
+			"ingredients": "2 cups flour\n2 eggs",
+			"directions":  "Mix.\nFry.",
+			"photo_data":  base64.StdEncoding.EncodeToString(photo),
+		}),
+	})
+
+	format, recipes, err := ReadArchive(data, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != Paprika || len(recipes) != 1 {
+		t.Fatalf("got %s with %d recipes, want 1 paprika recipe", format, len(recipes))
+	}
+
+	recipe := recipes[0]
+	if recipe.Err != nil || recipe.Recipe.Title != "Pancakes" {
+		t.Fatalf("got recipe %+v", recipe)
+	}
+	if recipe.Image == nil || recipe.Image.Name != "photo.jpg" {
+		t.Fatalf("got image %+v, want photo.jpg", recipe.Image)
+	}
+	image, err := recipe.Image.Read()
+	if err != nil || !bytes.Equal(image, photo) {
+		t.Errorf("Read() = %q, %v, want %q", image, err, photo)
+	}
+}
+
+func TestReadArchiveRejectsDecompressionBombs(t *testing.T) {
+	files := make(map[string][]byte)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		files[name+".cook"] = []byte(">> title: " + name + "\n" + strings.Repeat("Stir. ", 1<<20))
+	}
+	data := zipArchive(t, files)
+
+	_, _, err := ReadArchive(data, int64(len(data)))
+	if !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("ReadArchive() error = %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/units"
+)
+
+var (
+	cooklangBlockComment = regexp.MustCompile(`(?s)\[-.*?-\]`)
+	cooklangLineComment  = regexp.MustCompile(`(^|\s)--.*$`)
+	cooklangMetadata     = regexp.MustCompile(`^>>\s*([^:]+):\s*(.*)$`)
+	cooklangSection      = regexp.MustCompile(`^=+`)
+	cooklangIngredient   = regexp.MustCompile(`@(?:([^@#~{}\n]+?)\{([^}]*)\}|([\p{L}\p{N}_-]+))(?:\(([^)]*)\))?`)
+	cooklangCookware     = regexp.MustCompile(`#(?:([^@#~{}\n]+?)\{[^}]*\}|([\p{L}\p{N}_-]+))`)
+	cooklangTimer        = regexp.MustCompile(`~([^@#~{}\n]*?)\{([^}]*)\}`)
+
+	// cooklangGather matches what is left of the opening step our Cooklang
+	// export writes for ingredients no step mentions, once they are taken out
+	cooklangGather = regexp.MustCompile(`^Gather(?:\s*(?:,|and)\s*)*\s*\.?$`)
+)
+
+// ParseCooklang reads a recipe written in Cooklang. name, usually the file
+// name, is the title when the metadata doesn't give one.
+func ParseCooklang(source, name string) (*models.RecipeCreateRequest, error) {
+	source = cooklangBlockComment.ReplaceAllString(strings.ReplaceAll(source, "\r\n", "\n"), "")
+	metadata := make(map[string]string)
+
+	// YAML front matter, as written by newer Cooklang tools
+	if rest, ok := strings.CutPrefix(source, "---\n"); ok {
+		if header, body, found := strings.Cut(rest, "\n---\n"); found {
+			for _, line := range strings.Split(header, "\n") {
+				if key, value, ok := strings.Cut(line, ":"); ok {
+					metadata[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"'`)
+				}
+			}
+			source = body
+		}
+	}
+
+	var paragraphs []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if m := cooklangMetadata.FindStringSubmatch(line); m != nil {
+			metadata[strings.ToLower(strings.TrimSpace(m[1]))] = strings.TrimSpace(m[2])
+			continue
+		}
+		line = strings.TrimSpace(cooklangLineComment.ReplaceAllString(line, ""))
+		if line == "" || cooklangSection.MatchString(line) {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	req := &models.RecipeCreateRequest{Title: metadata["title"]}
+	if req.Title == "" {
+		req.Title = name
+	}
+	applyMetadata(req, metadata)
+
+	for _, paragraph := range paragraphs {
+		step := models.InstructionCreateRequest{}
+
+		text := cooklangIngredient.ReplaceAllStringFunc(paragraph, func(token string) string {
+			m := cooklangIngredient.FindStringSubmatch(token)
+			ingredient := models.IngredientCreateRequest{Name: strings.TrimSpace(m[1] + m[3])}
+			amount, unit, note := cooklangQuantity(m[2])
+			ingredient.Amount = amount
+			if unit != "" {
+				ingredient.Unit = &unit
+			}
+			if notes := strings.TrimSpace(strings.Trim(note+" "+m[4], " ")); notes != "" {
+				ingredient.Notes = &notes
+			}
+			addIngredient(req, ingredient)
+			return ingredient.Name
+		})
+		text = cooklangCookware.ReplaceAllString(text, "$1$2")
+		text = cooklangTimer.ReplaceAllStringFunc(text, func(token string) string {
+			m := cooklangTimer.FindStringSubmatch(token)
+			amount, unit, _ := cooklangQuantity(m[2])
+			if amount != nil && step.TimerMinutes == nil {
+				if minutes, ok := ParseMinutes(strconv.FormatFloat(*amount, 'f', -1, 64) + " " + unit); ok {
+					step.TimerMinutes = &minutes
+				}
+			}
+			return strings.TrimSpace(strings.Replace(m[2], "%", " ", 1))
+		})
+
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" || cooklangGather.MatchString(cooklangIngredient.ReplaceAllString(paragraph, "")) {
+			continue
+		}
+		step.Step = len(req.Instructions) + 1
+		step.Instruction = text
+		req.Instructions = append(req.Instructions, step)
+	}
+
+	return finishDraft(req)
+}
+
+// cooklangQuantity reads the "amount%unit" inside an ingredient or timer's
+// braces. Quantities that aren't numbers, like "some", are returned as a
+// note.
+func cooklangQuantity(s string) (*float64, string, string) {
+	quantity, unit, _ := strings.Cut(s, "%")
+	quantity = strings.TrimPrefix(strings.TrimSpace(quantity), "=")
+	unit = strings.TrimSpace(unit)
+	if u, ok := units.Lookup(unit); ok {
+		unit = u.Name
+	}
+	if quantity == "" {
+		return nil, unit, ""
+	}
+
+	if num, den, ok := strings.Cut(quantity, "/"); ok {
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err1 == nil && err2 == nil && d != 0 {
+			amount := n / d
+			return &amount, unit, ""
+		}
+	}
+	if amount, err := strconv.ParseFloat(strings.Replace(quantity, ",", ".", 1), 64); err == nil {
+		return &amount, unit, ""
+	}
+	return nil, unit, strings.TrimSpace(quantity + " " + unit)
+}
+
+// addIngredient adds an ingredient to a draft, adding up repeated mentions
+// of the same ingredient in the same unit.
+func addIngredient(req *models.RecipeCreateRequest, ingredient models.IngredientCreateRequest) {
+	for i := range req.Ingredients {
+		existing := &req.Ingredients[i]
+		if !strings.EqualFold(existing.Name, ingredient.Name) {
+			continue
+		}
+		if existing.Amount == nil && ingredient.Amount == nil {
+			return
+		}
+		if existing.Amount != nil && ingredient.Amount != nil && unitOf(existing) == unitOf(&ingredient) {
+			sum := *existing.Amount + *ingredient.Amount
+			existing.Amount = &sum
+			return
+		}
+	}
+	order := len(req.Ingredients)
+	ingredient.OrderIndex = &order
+	req.Ingredients = append(req.Ingredients, ingredient)
+}
+
+func unitOf(ingredient *models.IngredientCreateRequest) string {
+	if ingredient.Unit == nil {
+		return ""
+	}
+	return *ingredient.Unit
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var durationPattern = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration converts an ISO 8601 duration such as "PT1H30M" to whole
+// minutes.
+func ParseDuration(s string) (int, bool) {
+	m := durationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil || s == "" {
+		return 0, false
+	}
+
+	minutes := 0.0
+	for i, factor := range []float64{24 * 60, 60, 1, 1.0 / 60} {
+		if m[i+1] == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, false
+		}
+		minutes += value * factor
+	}
+	return int(minutes + 0.5), true
+}
+
+var (
+	minutesTextPattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(d|days?|h|hrs?|hours?|m|mins?|minutes?|s|secs?|seconds?)\b`)
+	bareNumberPattern  = regexp.MustCompile(`^\d+$`)
+)
+
+// ParseMinutes reads durations written either as ISO 8601 or as text such
+// as "1 hour 30 mins" or "45 min", as other recipe apps store them. A bare
+// number is taken as minutes.
+func ParseMinutes(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if minutes, ok := ParseDuration(s); ok {
+		return minutes, true
+	}
+	if bareNumberPattern.MatchString(s) {
+		minutes, err := strconv.Atoi(s)
+		return minutes, err == nil
+	}
+
+	matches := minutesTextPattern.FindAllStringSubmatch(s, -1)
+	if matches == nil {
+		return 0, false
+	}
+	minutes := 0.0
+	for _, m := range matches {
+		value, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		switch strings.ToLower(m[2])[0] {
+		case 'd':
+			minutes += value * 24 * 60
+		case 'h':
+			minutes += value * 60
+		case 'm':
+			minutes += value
+		case 's':
+			minutes += value / 60
+		}
+	}
+	return int(minutes + 0.5), true
+}
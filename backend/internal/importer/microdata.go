@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// microdataItems returns the top-level microdata items of a document in the
+// same shape as decoded JSON-LD, so both can be mapped the same way. Every
+// item carries its itemtype as "@type" and its properties as values or
+// slices of values.
+func microdataItems(doc *html.Node) []interface{} {
+	var items []interface{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && hasAttr(n, "itemscope") && !hasAttr(n, "itemprop") {
+			items = append(items, microdataItem(n))
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return items
+}
+
+func microdataItem(scope *html.Node) map[string]interface{} {
+	item := map[string]interface{}{}
+	if itemType := attr(scope, "itemtype"); itemType != "" {
+		item["@type"] = strings.Fields(itemType)[0]
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			if props := strings.Fields(attr(child, "itemprop")); len(props) > 0 {
+				var value interface{}
+				if hasAttr(child, "itemscope") {
+					value = microdataItem(child)
+				} else {
+					value = propertyValue(child)
+				}
+				for _, prop := range props {
+					addProperty(item, prop, value)
+				}
+			}
+
+			// Nested items own their descendants' properties
+			if !hasAttr(child, "itemscope") {
+				walk(child)
+			}
+		}
+	}
+	walk(scope)
+	return item
+}
+
+func addProperty(item map[string]interface{}, name string, value interface{}) {
+	existing, ok := item[name]
+	if !ok {
+		item[name] = value
+		return
+	}
+	if values, ok := existing.([]interface{}); ok {
+		item[name] = append(values, value)
+		return
+	}
+	item[name] = []interface{}{existing, value}
+}
+
+// propertyValue reads the value of an itemprop element following the
+// microdata rules for each element type.
+func propertyValue(n *html.Node) string {
+	switch n.Data {
+	case "meta":
+		return attr(n, "content")
+	case "img", "audio", "video", "source", "iframe", "embed":
+		return attr(n, "src")
+	case "a", "link", "area":
+		return attr(n, "href")
+	case "object":
+		return attr(n, "data")
+	case "time":
+		if value := attr(n, "datetime"); value != "" {
+			return value
+		}
+	case "data", "meter":
+		if value := attr(n, "value"); value != "" {
+			return value
+		}
+	}
+	if value := attr(n, "content"); value != "" {
+		return value
+	}
+	return textContent(n)
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "br" || n.Data == "p" || n.Data == "li"):
+			b.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, name string) bool {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,417 @@
+// Package importer extracts recipes from web pages that describe them with
+// schema.org Recipe markup, either as JSON-LD or as microdata.
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/ingredients"
+	"yummio-backend/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+// ErrNoRecipe is returned when a page has no schema.org Recipe markup.
+var ErrNoRecipe = errors.New("no recipe found on page")
+
+// recipeTypes maps recipeCategory values to our recipe types.
+var recipeTypes = map[string]string{
+	"breakfast":   "breakfast",
+	"brunch":      "breakfast",
+	"lunch":       "lunch",
+	"dinner":      "dinner",
+	"main":        "dinner",
+	"main course": "dinner",
+	"main dish":   "dinner",
+	"entree":      "dinner",
+	"dessert":     "dessert",
+	"desserts":    "dessert",
+	"snack":       "snack",
+	"snacks":      "snack",
+	"appetizer":   "snack",
+	"drink":       "drink",
+	"drinks":      "drink",
+	"beverage":    "drink",
+	"cocktail":    "drink",
+}
+
+// maxImportedTags caps the tags taken from categories and keywords, which
+// some blogs stuff with dozens of SEO terms.
+const maxImportedTags = 10
+
+var (
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+	blockEndPattern   = regexp.MustCompile(`(?i)<br\s*/?>|</(p|li|div)>`)
+	stepNumberPattern = regexp.MustCompile(`^\d+[.)]\s+`)
+	integerPattern    = regexp.MustCompile(`\d+`)
+	quantityPattern   = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*([a-zA-Zµ]*)`)
+)
+
+// Extract reads an HTML page and maps its schema.org Recipe into a recipe
+// draft. pageURL is used to resolve relative image links and is recorded as
+// the recipe's source; it may be empty for pasted HTML. The returned
+// warnings point out parts of the recipe the page didn't provide.
+func Extract(page io.Reader, pageURL string) (*models.RecipeCreateRequest, []string, error) {
+	doc, err := html.Parse(page)
+	if err != nil {
+		return nil, nil, ErrNoRecipe
+	}
+
+	recipe := findRecipe(jsonLDItems(doc))
+	if recipe == nil {
+		recipe = findRecipe(microdataItems(doc))
+	}
+	if recipe == nil {
+		return nil, nil, ErrNoRecipe
+	}
+
+	return mapRecipe(recipe, pageURL)
+}
+
+// jsonLDItems decodes every JSON-LD script of a document. Scripts that
+// aren't valid JSON are skipped.
+func jsonLDItems(doc *html.Node) []interface{} {
+	var items []interface{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" && strings.EqualFold(attr(n, "type"), "application/ld+json") {
+			var item interface{}
+			if err := json.Unmarshal([]byte(textContent(n)), &item); err == nil {
+				items = append(items, item)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return items
+}
+
+// findRecipe searches decoded items, including @graph lists and nested
+// entities such as mainEntity, for the first Recipe.
+func findRecipe(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if recipe := findRecipe(item); recipe != nil {
+				return recipe
+			}
+		}
+	case map[string]interface{}:
+		if isType(v, "Recipe") {
+			return v
+		}
+		for _, value := range v {
+			if recipe := findRecipe(value); recipe != nil {
+				return recipe
+			}
+		}
+	}
+	return nil
+}
+
+// isType reports whether an item has the given schema.org type, which may
+// be written as "Recipe", "schema:Recipe" or "https://schema.org/Recipe".
+func isType(item map[string]interface{}, name string) bool {
+	for _, t := range values(item["@type"]) {
+		t, _ := t.(string)
+		if t == name || strings.HasSuffix(t, "/"+name) || strings.HasSuffix(t, ":"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+func mapRecipe(item map[string]interface{}, pageURL string) (*models.RecipeCreateRequest, []string, error) {
+	var warnings []string
+	req := &models.RecipeCreateRequest{
+		Title: cleanText(text(item["name"])),
+	}
+	if req.Title == "" {
+		req.Title = cleanText(text(item["headline"]))
+	}
+	if req.Title == "" {
+		return nil, nil, ErrNoRecipe
+	}
+
+	if description := cleanText(text(item["description"])); description != "" {
+		req.Description = &description
+	}
+	if image := imageURL(item["image"], pageURL); image != "" {
+		req.ImageURL = &image
+	}
+
+	source := pageURL
+	if source == "" {
+		source = absoluteURL(text(item["url"]), "")
+	}
+	if source != "" {
+		req.SourceURL = &source
+	}
+
+	req.PrepTime = durationField(item["prepTime"])
+	req.CookTime = durationField(item["cookTime"])
+	if total := durationField(item["totalTime"]); total != nil && req.CookTime == nil {
+		cook := *total
+		if req.PrepTime != nil {
+			cook -= *req.PrepTime
+		}
+		if cook > 0 {
+			req.CookTime = &cook
+		}
+	}
+
+	for _, yield := range values(item["recipeYield"]) {
+		if n := integerPattern.FindString(text(yield)); n != "" {
+			servings, _ := strconv.Atoi(n)
+			if servings > 0 {
+				req.Servings = &servings
+				break
+			}
+		}
+	}
+
+	lines := values(item["recipeIngredient"])
+	if len(lines) == 0 {
+		lines = values(item["ingredients"])
+	}
+	for _, line := range lines {
+		line := cleanText(text(line))
+		if line == "" {
+			continue
+		}
+		ingredient := ingredients.Parse(line)
+		order := len(req.Ingredients)
+		ingredient.OrderIndex = &order
+		req.Ingredients = append(req.Ingredients, ingredient)
+	}
+	if len(req.Ingredients) == 0 {
+		warnings = append(warnings, "no ingredients found")
+	}
+
+	for i, step := range instructionSteps(item["recipeInstructions"]) {
+		step.Step = i + 1
+		req.Instructions = append(req.Instructions, step)
+	}
+	if len(req.Instructions) == 0 {
+		warnings = append(warnings, "no instructions found")
+	}
+
+	categories := append(values(item["recipeCategory"]), values(item["recipeCuisine"])...)
+	for _, category := range values(item["recipeCategory"]) {
+		if t, ok := recipeTypes[strings.ToLower(cleanText(text(category)))]; ok {
+			req.Type = &t
+			break
+		}
+	}
+	req.Tags = tags(append(categories, values(item["keywords"])...))
+
+	if nutrition, ok := item["nutrition"].(map[string]interface{}); ok {
+		req.Nutrition = mapNutrition(nutrition)
+	}
+
+	return req, warnings, nil
+}
+
+// instructionSteps flattens recipeInstructions, which may be a block of
+// text, a list of strings, HowToSteps or HowToSections of steps. A step's
+// timeRequired becomes its timer.
+func instructionSteps(v interface{}) []models.InstructionCreateRequest {
+	var steps []models.InstructionCreateRequest
+	switch v := v.(type) {
+	case string:
+		for _, line := range strings.Split(htmlToText(v), "\n") {
+			line = stepNumberPattern.ReplaceAllString(strings.TrimSpace(line), "")
+			if line != "" {
+				steps = append(steps, models.InstructionCreateRequest{Instruction: line})
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			steps = append(steps, instructionSteps(item)...)
+		}
+	case map[string]interface{}:
+		if isType(v, "HowToSection") || (v["text"] == nil && v["itemListElement"] != nil) {
+			return instructionSteps(v["itemListElement"])
+		}
+		step := cleanText(text(v["text"]))
+		if step == "" {
+			step = cleanText(text(v["name"]))
+		}
+		if step != "" {
+			steps = append(steps, models.InstructionCreateRequest{
+				Instruction:  step,
+				TimerMinutes: durationField(v["timeRequired"]),
+			})
+		}
+	}
+	return steps
+}
+
+func mapNutrition(item map[string]interface{}) *models.NutritionCreateRequest {
+	nutrition := &models.NutritionCreateRequest{}
+	found := false
+
+	if value, _, ok := quantity(item["calories"]); ok {
+		calories := int(value + 0.5)
+		nutrition.Calories = &calories
+		found = true
+	}
+
+	fields := []struct {
+		name  string
+		field **float64
+		unit  string
+	}{
+		{"proteinContent", &nutrition.Protein, "g"},
+		{"carbohydrateContent", &nutrition.Carbs, "g"},
+		{"fatContent", &nutrition.Fat, "g"},
+		{"fiberContent", &nutrition.Fiber, "g"},
+		{"sugarContent", &nutrition.Sugar, "g"},
+		{"sodiumContent", &nutrition.Sodium, "mg"},
+		{"cholesterolContent", &nutrition.Cholesterol, "mg"},
+	}
+	for _, f := range fields {
+		value, unit, ok := quantity(item[f.name])
+		if !ok {
+			continue
+		}
+		switch {
+		case f.unit == "mg" && unit == "g":
+			value *= 1000
+		case f.unit == "g" && unit == "mg":
+			value /= 1000
+		}
+		*f.field = &value
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return nutrition
+}
+
+// quantity reads values such as "240 kcal" or "12.5 g".
+func quantity(v interface{}) (float64, string, bool) {
+	m := quantityPattern.FindStringSubmatch(text(v))
+	if m == nil {
+		return 0, "", false
+	}
+	value, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return value, strings.ToLower(m[2]), true
+}
+
+func durationField(v interface{}) *int {
+	minutes, ok := ParseDuration(text(v))
+	if !ok || minutes == 0 {
+		return nil
+	}
+	return &minutes
+}
+
+func tags(v []interface{}) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, value := range v {
+		for _, tag := range strings.Split(text(value), ",") {
+			tag = cleanText(tag)
+			key := strings.ToLower(tag)
+			if tag == "" || len(tag) > 50 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, tag)
+			if len(result) == maxImportedTags {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// imageURL reads an image given as a URL, an ImageObject or a list of
+// either.
+func imageURL(v interface{}, base string) string {
+	for _, image := range values(v) {
+		if object, ok := image.(map[string]interface{}); ok {
+			image = object["url"]
+			if image == nil {
+				image = object["contentUrl"]
+			}
+		}
+		if u := absoluteURL(text(image), base); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// absoluteURL resolves a link against base and only accepts http(s) URLs.
+func absoluteURL(link, base string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || link == "" {
+		return ""
+	}
+	if b, err := url.Parse(base); err == nil && base != "" {
+		u = b.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// values returns v as a list, wrapping single values.
+func values(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+// text returns the text of a scalar value, the first usable value of a
+// list, or the @value of a typed literal.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		for _, item := range v {
+			if s := text(item); s != "" {
+				return s
+			}
+		}
+	case map[string]interface{}:
+		return text(v["@value"])
+	}
+	return ""
+}
+
+// htmlToText turns markup that sites leave in their JSON-LD into plain
+// text, keeping line breaks between blocks.
+func htmlToText(s string) string {
+	s = blockEndPattern.ReplaceAllString(s, "\n")
+	s = tagPattern.ReplaceAllString(s, "")
+	// Entities are often escaped twice, as in "&amp;#39;"
+	return html.UnescapeString(html.UnescapeString(s))
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(htmlToText(s)), " ")
+}
@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"yummio-backend/internal/models"
+)
+
+// ingredientLine writes an imported ingredient compactly for comparison.
+func ingredientLine(ingredient models.IngredientCreateRequest) string {
+	var parts []string
+	if ingredient.Amount != nil {
+		parts = append(parts, strconv.FormatFloat(*ingredient.Amount, 'f', -1, 64))
+	}
+	if ingredient.Unit != nil {
+		parts = append(parts, *ingredient.Unit)
+	}
+	parts = append(parts, ingredient.Name)
+	if ingredient.Notes != nil {
+		parts = append(parts, "("+*ingredient.Notes+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+func intValue(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func TestExtract(t *testing.T) {
+	type step struct {
+		Instruction string
+		Timer       int
+	}
+	tests := []struct {
+		fixture      string
+		pageURL      string
+		title        string
+		description  string
+		image        string
+		prepTime     int
+		cookTime     int
+		servings     int
+		recipeType   string
+		tags         []string
+		ingredients  []string
+		instructions []step
+		calories     int
+	}{
+		{
+			fixture:     "jsonld_graph.html",
+			pageURL:     "https://blog.example.com/recipes/chili",
+			title:       "Weeknight Chili",
+			description: "A quick chili for busy nights & lazy weekends.",
+			image:       "https://blog.example.com/images/chili.jpg",
+			prepTime:    15,
+			cookTime:    45,
+			servings:    6,
+			recipeType:  "dinner",
+			tags:        []string{"Main Course", "Tex-Mex", "chili", "beans"},
+			ingredients: []string{
+				"1 lb ground beef",
+				"1 can diced tomatoes (14 oz)",
+				"2 clove garlic (minced; up to 3)",
+				"Salt (to taste)",
+			},
+			instructions: []step{
+				{"Brown the beef in a large pot.", 0},
+				{"Add the tomatoes and garlic.", 30},
+				{"Season with salt and serve.", 0},
+			},
+			calories: 420,
+		},
+		{
+			fixture:     "microdata.html",
+			pageURL:     "https://cookies.example.com/shortbread/",
+			title:       "Lemon Shortbread",
+			description: "Buttery cookies with lemon zest.",
+			image:       "https://cookies.example.com/shortbread/shortbread.jpg",
+			prepTime:    20,
+			cookTime:    25,
+			servings:    24,
+			recipeType:  "dessert",
+			tags:        []string{"Dessert"},
+			ingredients: []string{
+				"1.5 cup all-purpose flour",
+				"0.5 cup sugar",
+				"1 cup butter (softened)",
+			},
+			instructions: []step{
+				{"Cream the butter and sugar.", 0},
+				{"Stir in the flour.", 0},
+				{"Bake until golden.", 0},
+			},
+			calories: 110,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			page, err := os.Open(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer page.Close()
+
+			recipe, warnings, err := Extract(page, tt.pageURL)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("warnings = %v, want none", warnings)
+			}
+
+			if recipe.Title != tt.title {
+				t.Errorf("title = %q, want %q", recipe.Title, tt.title)
+			}
+			if got := stringValue(recipe.Description); got != tt.description {
+				t.Errorf("description = %q, want %q", got, tt.description)
+			}
+			if got := stringValue(recipe.ImageURL); got != tt.image {
+				t.Errorf("image = %q, want %q", got, tt.image)
+			}
+			if got := stringValue(recipe.SourceURL); got != tt.pageURL {
+				t.Errorf("source = %q, want %q", got, tt.pageURL)
+			}
+			if got := intValue(recipe.PrepTime); got != tt.prepTime {
+				t.Errorf("prep time = %d, want %d", got, tt.prepTime)
+			}
+			if got := intValue(recipe.CookTime); got != tt.cookTime {
+				t.Errorf("cook time = %d, want %d", got, tt.cookTime)
+			}
+			if got := intValue(recipe.Servings); got != tt.servings {
+				t.Errorf("servings = %d, want %d", got, tt.servings)
+			}
+			if got := stringValue(recipe.Type); got != tt.recipeType {
+				t.Errorf("type = %q, want %q", got, tt.recipeType)
+			}
+			if !reflect.DeepEqual(recipe.Tags, tt.tags) {
+				t.Errorf("tags = %q, want %q", recipe.Tags, tt.tags)
+			}
+
+			var lines []string
+			for i, ingredient := range recipe.Ingredients {
+				if intValue(ingredient.OrderIndex) != i {
+					t.Errorf("ingredient %d has order index %d", i, intValue(ingredient.OrderIndex))
+				}
+				lines = append(lines, ingredientLine(ingredient))
+			}
+			if !reflect.DeepEqual(lines, tt.ingredients) {
+				t.Errorf("ingredients = %q, want %q", lines, tt.ingredients)
+			}
+
+			var steps []step
+			for i, instruction := range recipe.Instructions {
+				if instruction.Step != i+1 {
+					t.Errorf("instruction %d is numbered %d", i, instruction.Step)
+				}
+				steps = append(steps, step{instruction.Instruction, intValue(instruction.TimerMinutes)})
+			}
+			if !reflect.DeepEqual(steps, tt.instructions) {
+				t.Errorf("instructions = %v, want %v", steps, tt.instructions)
+			}
+
+			if recipe.Nutrition == nil {
+				t.Fatal("nutrition is missing")
+			}
+			if got := intValue(recipe.Nutrition.Calories); got != tt.calories {
+				t.Errorf("calories = %d, want %d", got, tt.calories)
+			}
+		})
+	}
+}
+
+func TestExtractNutritionUnits(t *testing.T) {
+	page, err := os.Open(filepath.Join("testdata", "jsonld_graph.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer page.Close()
+
+	recipe, _, err := Extract(page, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Nutrition.Protein == nil || *recipe.Nutrition.Protein != 31 {
+		t.Errorf("protein = %v, want 31 g", recipe.Nutrition.Protein)
+	}
+	if recipe.Nutrition.Sodium == nil || *recipe.Nutrition.Sodium != 600 {
+		t.Errorf("sodium = %v, want 600 mg", recipe.Nutrition.Sodium)
+	}
+	if recipe.ImageURL != nil {
+		t.Errorf("relative image without a page URL = %q, want none", *recipe.ImageURL)
+	}
+}
+
+func TestExtractWithoutRecipe(t *testing.T) {
+	page := `<html><head><script type="application/ld+json">{"@type": "WebSite", "name": "Blog"}</script></head>
+<body><div itemscope itemtype="http://schema.org/Person"><span itemprop="name">Cook</span></div></body></html>`
+
+	if _, _, err := Extract(strings.NewReader(page), ""); err != ErrNoRecipe {
+		t.Errorf("Extract() error = %v, want %v", err, ErrNoRecipe)
+	}
+}
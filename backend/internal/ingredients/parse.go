@@ -0,0 +1,249 @@
+// Package ingredients parses free-text ingredient lines such as
+// "1 1/2 cups (190 g) all-purpose flour, sifted" into structured
+// ingredients.
+package ingredients
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/units"
+)
+
+// countUnits are units that aren't measures but are commonly written in
+// front of an ingredient, mapped to their singular form.
+var countUnits = map[string]string{
+	"clove":    "clove",
+	"cloves":   "clove",
+	"can":      "can",
+	"cans":     "can",
+	"pinch":    "pinch",
+	"pinches":  "pinch",
+	"dash":     "dash",
+	"dashes":   "dash",
+	"slice":    "slice",
+	"slices":   "slice",
+	"stick":    "stick",
+	"sticks":   "stick",
+	"bunch":    "bunch",
+	"bunches":  "bunch",
+	"sprig":    "sprig",
+	"sprigs":   "sprig",
+	"handful":  "handful",
+	"handfuls": "handful",
+	"package":  "package",
+	"packages": "package",
+	"piece":    "piece",
+	"pieces":   "piece",
+}
+
+// vulgarFractions maps unicode fraction characters to their values.
+var vulgarFractions = map[rune]float64{
+	'½': 1.0 / 2,
+	'⅓': 1.0 / 3,
+	'⅔': 2.0 / 3,
+	'¼': 1.0 / 4,
+	'¾': 3.0 / 4,
+	'⅕': 1.0 / 5,
+	'⅖': 2.0 / 5,
+	'⅗': 3.0 / 5,
+	'⅘': 4.0 / 5,
+	'⅙': 1.0 / 6,
+	'⅚': 5.0 / 6,
+	'⅛': 1.0 / 8,
+	'⅜': 3.0 / 8,
+	'⅝': 5.0 / 8,
+	'⅞': 7.0 / 8,
+}
+
+// number matches a whole number and a fraction, a fraction, a number with
+// thousands separators such as "1,000" or a decimal with a point or comma.
+const (
+	thousands = `\d{1,3}(?:,\d{3})+(?:\.\d+)?`
+	number    = `\d+\s+\d+/\d+|\d+/\d+|` + thousands + `|\d+(?:[.,]\d+)?`
+)
+
+var (
+	// amountPattern matches a leading amount or range of amounts, such as
+	// "1 1/2", "2-3" or "2 to 3".
+	amountPattern = regexp.MustCompile(`^(` + number + `)(?:\s*(?:-|–|—|to|or)\s*(` + number + `))?\s*`)
+
+	// fractionPattern matches unicode fractions with an optional whole
+	// number in front, as in "1½" or "1 ½".
+	fractionPattern = regexp.MustCompile(`(\d*)\s*([½⅓⅔¼¾⅕⅖⅗⅘⅙⅚⅛⅜⅝⅞])`)
+
+	// fractionSlash normalises the fraction slash some sites use.
+	fractionSlash = strings.NewReplacer("⁄", "/", "∕", "/")
+
+	parenthesesPattern = regexp.MustCompile(`\s*\(([^()]*)\)`)
+
+	// measurementPattern matches text that is only a measurement, such as
+	// the "190 g" in "1 1/2 cups (190 g) flour".
+	measurementPattern = regexp.MustCompile(`^(?:about |approx\.? |approximately )?(` + number + `)\s*([a-zA-Z. ]+)$`)
+
+	thousandsPattern = regexp.MustCompile(`^` + thousands + `$`)
+
+	toTastePattern = regexp.MustCompile(`(?i)[,\s]+(to taste|as needed|optional)$`)
+)
+
+// Parse turns an ingredient line into a structured ingredient. Ranges keep
+// their lower bound as the amount and note the upper one, alternate
+// measurements in parentheses are dropped and other parenthetical remarks
+// become notes. Whatever can't be recognised stays in the name, so no text
+// is ever lost.
+func Parse(line string) models.IngredientCreateRequest {
+	rest := normalize(line)
+	var ingredient models.IngredientCreateRequest
+	var notes []string
+
+	upper := ""
+	if m := amountPattern.FindStringSubmatch(rest); m != nil {
+		if amount, ok := parseAmount(m[1]); ok {
+			ingredient.Amount = &amount
+			rest = rest[len(m[0]):]
+			if high, ok := parseAmount(m[2]); ok && high > amount {
+				upper = formatAmount(high)
+			}
+		}
+	}
+
+	// "1 (14 oz) can tomatoes" gives the package size before the unit
+	if m := parenthesesPattern.FindStringSubmatchIndex(rest); m != nil && m[0] == 0 {
+		notes = append(notes, strings.TrimSpace(rest[m[2]:m[3]]))
+		rest = strings.TrimSpace(rest[m[1]:])
+	}
+
+	unit, length := parseUnit(rest)
+	if unit != "" {
+		ingredient.Unit = &unit
+		rest = strings.TrimSpace(rest[length:])
+		rest = strings.TrimPrefix(rest, "of ")
+	}
+	if upper != "" {
+		notes = append(notes, "up to "+strings.TrimSpace(upper+" "+unitLabel(unit)))
+	}
+
+	// Parenthetical remarks, except alternate measurements of a measured
+	// amount, become notes
+	_, measured := units.Lookup(unit)
+	rest = parenthesesPattern.ReplaceAllStringFunc(rest, func(group string) string {
+		remark := strings.TrimSpace(parenthesesPattern.FindStringSubmatch(group)[1])
+		if remark == "" || (measured && isMeasurement(remark)) {
+			return ""
+		}
+		notes = append(notes, remark)
+		return ""
+	})
+
+	name, remark, _ := strings.Cut(rest, ",")
+	if m := toTastePattern.FindStringSubmatchIndex(name); m != nil {
+		notes = append(notes, strings.ToLower(name[m[2]:m[3]]))
+		name = name[:m[0]]
+	}
+	if remark = strings.TrimSpace(remark); remark != "" {
+		notes = append([]string{remark}, notes...)
+	}
+
+	ingredient.Name = strings.TrimSpace(name)
+	if ingredient.Name == "" {
+		ingredient.Name = strings.TrimSpace(line)
+	}
+	if len(notes) > 0 {
+		joined := strings.Join(notes, "; ")
+		ingredient.Notes = &joined
+	}
+	return ingredient
+}
+
+// normalize collapses whitespace and rewrites unicode fractions as ASCII
+// ones, so "1½" reads as "1 1/2".
+func normalize(line string) string {
+	line = fractionSlash.Replace(line)
+	line = fractionPattern.ReplaceAllStringFunc(line, func(s string) string {
+		m := fractionPattern.FindStringSubmatch(s)
+		value := vulgarFractions[[]rune(m[2])[0]]
+		if m[1] != "" {
+			whole, _ := strconv.ParseFloat(m[1], 64)
+			value += whole
+		}
+		return formatAmount(value) + " "
+	})
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// parseAmount reads a number, a fraction or a whole number and a fraction.
+func parseAmount(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	total := 0.0
+	for _, part := range strings.Fields(s) {
+		if num, den, ok := strings.Cut(part, "/"); ok {
+			n, err1 := strconv.ParseFloat(num, 64)
+			d, err2 := strconv.ParseFloat(den, 64)
+			if err1 != nil || err2 != nil || d == 0 {
+				return 0, false
+			}
+			total += n / d
+			continue
+		}
+
+		if thousandsPattern.MatchString(part) {
+			part = strings.ReplaceAll(part, ",", "")
+		} else {
+			part = strings.Replace(part, ",", ".", 1)
+		}
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		total += value
+	}
+	return total, true
+}
+
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// parseUnit recognises a unit at the start of s and returns its canonical
+// name and the length of the text it was read from.
+func parseUnit(s string) (string, int) {
+	words := strings.SplitN(s, " ", 3)
+	for n := min(2, len(words)-1); n >= 1; n-- {
+		text := strings.Join(words[:n], " ")
+		if unit, ok := lookupUnit(text); ok {
+			return unit, len(text)
+		}
+	}
+	return "", 0
+}
+
+func lookupUnit(text string) (string, bool) {
+	word := strings.TrimSuffix(strings.ToLower(text), ".")
+	if u, ok := units.Lookup(word); ok {
+		return u.Name, true
+	}
+	unit, ok := countUnits[word]
+	return unit, ok
+}
+
+// unitLabel spells a unit for notes, leaving count units out since the
+// name already says what is counted.
+func unitLabel(unit string) string {
+	if _, ok := units.Lookup(unit); ok {
+		return unit
+	}
+	return ""
+}
+
+func isMeasurement(s string) bool {
+	m := measurementPattern.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return false
+	}
+	_, ok := units.Lookup(strings.TrimSuffix(strings.TrimSpace(m[2]), "."))
+	return ok
+}
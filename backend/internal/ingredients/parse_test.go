@@ -0,0 +1,75 @@
+package ingredients
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line   string
+		amount float64 // 0 when there is none
+		unit   string
+		name   string
+		notes  string
+	}{
+		// Unicode fractions
+		{"½ cup sugar", 0.5, "cup", "sugar", ""},
+		{"1½ cups milk", 1.5, "cup", "milk", ""},
+		{"1 ¾ tsp baking powder", 1.75, "tsp", "baking powder", ""},
+		{"1⁄3 cup honey", 1.0 / 3, "cup", "honey", ""},
+		{"2 1/2 tablespoons olive oil", 2.5, "tbsp", "olive oil", ""},
+		{"1,5 l water", 1.5, "l", "water", ""},
+		{"1,000 g flour", 1000, "g", "flour", ""},
+		{"1,000 ml stock", 1000, "ml", "stock", ""},
+		{"2,500.5 g potatoes", 2500.5, "g", "potatoes", ""},
+		{"0,75 l cream", 0.75, "l", "cream", ""},
+
+		// Ranges
+		{"2-3 cloves garlic, minced", 2, "clove", "garlic", "minced; up to 3"},
+		{"2 to 3 tbsp lemon juice", 2, "tbsp", "lemon juice", "up to 3 tbsp"},
+		{"1–2 pinches salt", 1, "pinch", "salt", "up to 2"},
+		{"3-3 cups stock", 3, "cup", "stock", ""},
+
+		// Parenthetical alternates and remarks
+		{"1 1/2 cups (190 g) all-purpose flour, sifted", 1.5, "cup", "all-purpose flour", "sifted"},
+		{"1 cup (about 2 sticks) butter", 1, "cup", "butter", "about 2 sticks"},
+		{"1 (14 oz) can diced tomatoes", 1, "can", "diced tomatoes", "14 oz"},
+		{"2 eggs (room temperature)", 2, "", "eggs", "room temperature"},
+		{"200 g (7 oz) dark chocolate", 200, "g", "dark chocolate", ""},
+
+		// No amount
+		{"Salt to taste", 0, "", "Salt", "to taste"},
+		{"fresh basil, optional", 0, "", "fresh basil", "optional"},
+		{"Juice of 1 lemon", 0, "", "Juice of 1 lemon", ""},
+		{"(for serving)", 0, "", "(for serving)", "for serving"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := Parse(tt.line)
+
+			amount := 0.0
+			if got.Amount != nil {
+				amount = *got.Amount
+			}
+			if diff := amount - tt.amount; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("amount = %v, want %v", amount, tt.amount)
+			}
+			unit := ""
+			if got.Unit != nil {
+				unit = *got.Unit
+			}
+			if unit != tt.unit {
+				t.Errorf("unit = %q, want %q", unit, tt.unit)
+			}
+			if got.Name != tt.name {
+				t.Errorf("name = %q, want %q", got.Name, tt.name)
+			}
+			notes := ""
+			if got.Notes != nil {
+				notes = *got.Notes
+			}
+			if notes != tt.notes {
+				t.Errorf("notes = %q, want %q", notes, tt.notes)
+			}
+		})
+	}
+}
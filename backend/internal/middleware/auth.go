@@ -89,6 +89,19 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware authenticates requests that carry a token and lets
+// anonymous ones through, for routes that serve more to signed-in users.
+func OptionalAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+	auth := AuthMiddleware(jwtSecret)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
 // Helper function to get user ID from context
 func GetUserID(c *gin.Context) (uuid.UUID, bool) {
 	userID, exists := c.Get("user_id")
@@ -0,0 +1,40 @@
+package models
+
+import "github.com/google/uuid"
+
+// CookMode is a recipe laid out for a step-by-step kitchen screen.
+type CookMode struct {
+	RecipeID    uuid.UUID          `json:"recipe_id"`
+	Title       string             `json:"title"`
+	Servings    *int               `json:"servings,omitempty"`
+	MiseEnPlace []MiseEnPlaceGroup `json:"mise_en_place"`
+	Steps       []CookModeStep     `json:"steps"`
+}
+
+// MiseEnPlaceGroup lists the ingredients to get ready before a step: the
+// ones it is the first to use.
+type MiseEnPlaceGroup struct {
+	Step        *int                 `json:"step,omitempty"` // unset for ingredients no step uses
+	Ingredients []CookModeIngredient `json:"ingredients"`
+}
+
+type CookModeStep struct {
+	Step         int                  `json:"step"`
+	Instruction  string               `json:"instruction"`
+	ImageURL     *string              `json:"image_url,omitempty"`
+	TimerMinutes *int                 `json:"timer_minutes,omitempty"`
+	Ingredients  []CookModeIngredient `json:"ingredients"`
+}
+
+// CookModeIngredient is an ingredient as a step uses it. Amount and Unit are
+// the part the step uses; they are left out when an ingredient found in the
+// text was already used by an earlier step.
+type CookModeIngredient struct {
+	IngredientID uuid.UUID  `json:"ingredient_id"`
+	Name         string     `json:"name"`
+	Amount       *float64   `json:"amount,omitempty"`
+	Unit         *string    `json:"unit,omitempty"`
+	Notes        *string    `json:"notes,omitempty"`
+	SubRecipeID  *uuid.UUID `json:"sub_recipe_id,omitempty"`
+	Matched      bool       `json:"matched,omitempty"` // found in the step's text rather than linked by the author
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImportJob tracks the background import of a recipe archive exported from
+// another recipe manager.
+type ImportJob struct {
+	ID          uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID         `json:"user_id" gorm:"type:uuid;not null;index"`
+	Format      string            `json:"format" gorm:"not null"` // paprika, mealie, cooklang
+	Filename    string            `json:"filename"`
+	Status      string            `json:"status" gorm:"not null;default:'pending'"` // pending, running, completed, failed
+	Total       int               `json:"total"`
+	Imported    int               `json:"imported"`
+	Skipped     int               `json:"skipped"`
+	Failed      int               `json:"failed"`
+	Results     []ImportJobResult `json:"results" gorm:"type:jsonb;serializer:json"`
+	Error       *string           `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// ImportJobResult reports what happened to one recipe of an archive.
+type ImportJobResult struct {
+	Source   string     `json:"source"` // archive entry the recipe was read from
+	Title    string     `json:"title,omitempty"`
+	Status   string     `json:"status"` // imported, skipped, failed
+	RecipeID *uuid.UUID `json:"recipe_id,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Warning  string     `json:"warning,omitempty"`
+}
+
+func (j *ImportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MealPlanEntry struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	RecipeID  uuid.UUID `json:"recipe_id" gorm:"type:uuid;not null"`
+	Date      time.Time `json:"date" gorm:"type:date;not null"`
+	MealType  string    `json:"meal_type" gorm:"not null"` // breakfast, lunch, dinner, dessert, snack, drink
+	Servings  *int      `json:"servings,omitempty"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	User   User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Recipe Recipe `json:"recipe,omitempty" gorm:"foreignKey:RecipeID"`
+}
+
+// MealPlanFeedToken grants read access to a user's meal plan calendar feed.
+// Only a hash of the token is stored so it can be revoked without affecting
+// the user's sessions.
+type MealPlanFeedToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TokenHash string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MealPlanFeedResponse struct {
+	Token   string `json:"token"`
+	FeedURL string `json:"feed_url"`
+}
+
+// MealPlan is the set of entries a user planned for a date range.
+type MealPlan struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Entries []MealPlanEntry `json:"entries"`
+}
+
+type MealPlanEntryCreateRequest struct {
+	RecipeID uuid.UUID `json:"recipe_id" validate:"required"`
+	Date     string    `json:"date" validate:"required,datetime=2006-01-02"`
+	MealType string    `json:"meal_type" validate:"required,oneof=breakfast lunch dinner dessert snack drink"`
+	Servings *int      `json:"servings,omitempty" validate:"omitempty,min=1"`
+	Note     *string   `json:"note,omitempty" validate:"omitempty,max=500"`
+}
+
+type MealPlanEntryUpdateRequest struct {
+	RecipeID *uuid.UUID `json:"recipe_id,omitempty"`
+	Date     *string    `json:"date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	MealType *string    `json:"meal_type,omitempty" validate:"omitempty,oneof=breakfast lunch dinner dessert snack drink"`
+	Servings *int       `json:"servings,omitempty" validate:"omitempty,min=1"`
+	Note     *string    `json:"note,omitempty" validate:"omitempty,max=500"`
+}
+
+type MealPlanEntryMoveRequest struct {
+	Date     string  `json:"date" validate:"required,datetime=2006-01-02"`
+	MealType *string `json:"meal_type,omitempty" validate:"omitempty,oneof=breakfast lunch dinner dessert snack drink"`
+}
+
+type MealPlanRepeatWeekRequest struct {
+	FromWeek string `json:"from_week" validate:"required,datetime=2006-01-02"`
+	ToWeek   string `json:"to_week" validate:"required,datetime=2006-01-02"`
+}
+
+type MealPlanShoppingListRequest struct {
+	Name           *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	IncludeStaples bool    `json:"include_staples"`
+}
+
+func (m *MealPlanEntry) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (m *MealPlanFeedToken) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
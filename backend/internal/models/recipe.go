@@ -21,10 +21,24 @@ type Recipe struct {
 	Rating      float64        `json:"rating" gorm:"default:0"`
 	RatingCount int            `json:"rating_count" gorm:"default:0"`
 	IsPublic    bool           `json:"is_public" gorm:"default:true"`
+	SourceURL   *string        `json:"source_url,omitempty"` // page the recipe was imported from
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Derived from the ingredients when the recipe is saved
+	DietaryLabels  []string            `json:"dietary_labels" gorm:"type:jsonb;serializer:json"`
+	DietaryReasons map[string][]string `json:"dietary_reasons,omitempty" gorm:"type:jsonb;serializer:json"` // why each missing label was denied
+	Allergens      []string            `json:"allergens,omitempty" gorm:"type:jsonb;serializer:json"`       // allergen classes, sub-recipes included
+
+	// Forking copies a recipe into another account
+	ForkedFromID *uuid.UUID `json:"forked_from_id,omitempty" gorm:"type:uuid;index"` // recipe this one was copied from
+	ForkCount    int        `json:"fork_count" gorm:"default:0"`                     // forks of this recipe, public or not
+
+	// Only published recipes are listed, whether public or not
+	Status    string     `json:"status" gorm:"not null;default:'published';index;check:status IN ('draft','published','archived')"`
+	PublishAt *time.Time `json:"publish_at,omitempty" gorm:"index"` // when a scheduled draft goes public
+
 	// Relationships
 	User         User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Ingredients  []Ingredient  `json:"ingredients,omitempty" gorm:"foreignKey:RecipeID;constraint:OnDelete:CASCADE"`
@@ -33,6 +47,31 @@ type Recipe struct {
 	Collections  []Collection  `json:"collections,omitempty" gorm:"many2many:collection_recipes;"`
 	Ratings      []Rating      `json:"ratings,omitempty" gorm:"foreignKey:RecipeID"`
 	Nutrition    *Nutrition    `json:"nutrition,omitempty" gorm:"foreignKey:RecipeID"`
+
+	// Credits the original of a forked recipe while it still exists
+	ForkedFrom *RecipeAttribution `json:"forked_from,omitempty" gorm:"-"`
+}
+
+// Recipe statuses. Drafts are still being written and archived recipes are
+// retired; neither is listed publicly.
+const (
+	RecipeStatusDraft     = "draft"
+	RecipeStatusPublished = "published"
+	RecipeStatusArchived  = "archived"
+)
+
+// IsListed reports whether the recipe appears in public listings.
+func (r *Recipe) IsListed() bool {
+	return r.IsPublic && r.Status == RecipeStatusPublished
+}
+
+// RecipeAttribution credits the recipe a fork was copied from and its
+// author.
+type RecipeAttribution struct {
+	ID         uuid.UUID `json:"id"`
+	Title      string    `json:"title"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	AuthorName string    `json:"author_name"`
 }
 
 type Ingredient struct {
@@ -43,6 +82,22 @@ type Ingredient struct {
 	Unit       *string   `json:"unit,omitempty"`
 	Notes      *string   `json:"notes,omitempty"`
 	OrderIndex int       `json:"order_index" gorm:"default:0"`
+
+	// Another recipe made as this ingredient, such as a pie crust. The
+	// amount counts servings of it with the unit "servings" and batches of
+	// it without a unit; with any other unit one batch is made
+	SubRecipeID *uuid.UUID       `json:"sub_recipe_id,omitempty" gorm:"type:uuid;index"`
+	SubRecipe   *RecipeComponent `json:"sub_recipe,omitempty" gorm:"-"` // set when the sub-recipe can be shown
+}
+
+// RecipeComponent is a recipe used as an ingredient of another, shown
+// with its own ingredients.
+type RecipeComponent struct {
+	ID          uuid.UUID    `json:"id"`
+	Title       string       `json:"title"`
+	ImageURL    *string      `json:"image_url,omitempty"`
+	Servings    *int         `json:"servings,omitempty"`
+	Ingredients []Ingredient `json:"ingredients"`
 }
 
 type Instruction struct {
@@ -52,6 +107,17 @@ type Instruction struct {
 	Instruction  string    `json:"instruction" gorm:"not null" validate:"required"`
 	ImageURL     *string   `json:"image_url,omitempty"`
 	TimerMinutes *int      `json:"timer_minutes,omitempty"`
+
+	// Ingredients the author linked to this step
+	Ingredients []StepIngredient `json:"ingredients,omitempty" gorm:"type:jsonb;serializer:json"`
+}
+
+// StepIngredient links a step to an ingredient it uses. Amount and Unit are
+// set when the step uses only part of it, such as half the sugar.
+type StepIngredient struct {
+	IngredientID uuid.UUID `json:"ingredient_id"`
+	Amount       *float64  `json:"amount,omitempty"`
+	Unit         *string   `json:"unit,omitempty"`
 }
 
 type Tag struct {
@@ -86,6 +152,11 @@ type Nutrition struct {
 	Sugar        *float64  `json:"sugar,omitempty"`        // grams
 	Sodium       *float64  `json:"sodium,omitempty"`       // milligrams
 	Cholesterol  *float64  `json:"cholesterol,omitempty"`  // milligrams
+
+	// Set when the values were estimated from the ingredients rather than
+	// entered by the author
+	Calculated           bool     `json:"calculated" gorm:"default:false"`
+	UnmatchedIngredients []string `json:"unmatched_ingredients,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
 type RecipeCreateRequest struct {
@@ -98,18 +169,23 @@ type RecipeCreateRequest struct {
 	Difficulty   *string                    `json:"difficulty,omitempty"`
 	Type         *string                    `json:"type,omitempty"`
 	IsPublic     *bool                      `json:"is_public,omitempty"`
+	Status       *string                    `json:"status,omitempty" validate:"omitempty,oneof=draft published archived"`
+	PublishAt    *time.Time                 `json:"publish_at,omitempty"`
+	SourceURL    *string                    `json:"source_url,omitempty" validate:"omitempty,url,max=2048"`
 	Ingredients  []IngredientCreateRequest  `json:"ingredients,omitempty"`
+	Lines        []string                   `json:"lines,omitempty" validate:"max=200,dive,max=500"` // raw ingredient lines, parsed when ingredients is empty
 	Instructions []InstructionCreateRequest `json:"instructions,omitempty"`
 	Tags         []string                   `json:"tags,omitempty"`
 	Nutrition    *NutritionCreateRequest    `json:"nutrition,omitempty"`
 }
 
 type IngredientCreateRequest struct {
-	Name       string   `json:"name" validate:"required"`
-	Amount     *float64 `json:"amount,omitempty"`
-	Unit       *string  `json:"unit,omitempty"`
-	Notes      *string  `json:"notes,omitempty"`
-	OrderIndex *int     `json:"order_index,omitempty"`
+	Name        string     `json:"name" validate:"required_without=SubRecipeID"` // defaults to the sub-recipe's title
+	Amount      *float64   `json:"amount,omitempty"`
+	Unit        *string    `json:"unit,omitempty"`
+	Notes       *string    `json:"notes,omitempty"`
+	OrderIndex  *int       `json:"order_index,omitempty"`
+	SubRecipeID *uuid.UUID `json:"sub_recipe_id,omitempty"`
 }
 
 type InstructionCreateRequest struct {
@@ -117,6 +193,18 @@ type InstructionCreateRequest struct {
 	Instruction  string  `json:"instruction" validate:"required"`
 	ImageURL     *string `json:"image_url,omitempty"`
 	TimerMinutes *int    `json:"timer_minutes,omitempty"`
+
+	// Ingredients the step uses
+	Ingredients []StepIngredientRequest `json:"ingredients,omitempty"`
+}
+
+// StepIngredientRequest links a step to the ingredient at position
+// Ingredient (counting from 0) of the recipe's ingredients. Amount and Unit
+// are the part the step uses, when that isn't all of it.
+type StepIngredientRequest struct {
+	Ingredient *int     `json:"ingredient" validate:"required,min=0"`
+	Amount     *float64 `json:"amount,omitempty"`
+	Unit       *string  `json:"unit,omitempty"`
 }
 
 type NutritionCreateRequest struct {
@@ -130,6 +218,35 @@ type NutritionCreateRequest struct {
 	Cholesterol *float64 `json:"cholesterol,omitempty"`
 }
 
+type ParseIngredientsRequest struct {
+	Lines []string `json:"lines" validate:"required,min=1,max=200,dive,max=500"`
+}
+
+// RecipeImportRequest imports a recipe from a web page, given by URL or as
+// pasted HTML. Without Confirm only a draft is returned. Confirming saves
+// Recipe, the draft as edited by the user, or else the extracted draft.
+type RecipeImportRequest struct {
+	URL     string               `json:"url,omitempty" validate:"required_without=HTML,omitempty,url,max=2048"`
+	HTML    string               `json:"html,omitempty" validate:"required_without=URL,max=5000000"`
+	Confirm bool                 `json:"confirm"`
+	Recipe  *RecipeCreateRequest `json:"recipe,omitempty"`
+}
+
+// RecipeImportPreview is a recipe draft extracted from a web page. Warnings
+// point out what the page didn't provide.
+type RecipeImportPreview struct {
+	Recipe   RecipeCreateRequest `json:"recipe"`
+	Warnings []string            `json:"warnings"`
+}
+
+// PrintQuery holds the options of a printed recipe or collection booklet.
+type PrintQuery struct {
+	Format   string  `form:"format,default=html" validate:"oneof=html pdf"`
+	Servings *int    `form:"servings" validate:"omitempty,min=1"`
+	System   *string `form:"system" validate:"omitempty,oneof=metric imperial"`
+	Columns  int     `form:"columns,default=1" validate:"oneof=1 2"` // 2 sets ingredients beside the instructions
+}
+
 type RateRecipeRequest struct {
 	Rating int     `json:"rating" validate:"required,min=1,max=5"`
 	Review *string `json:"review,omitempty"`
@@ -145,6 +262,65 @@ type RecipeQuery struct {
 	UserID     *string  `form:"user_id,omitempty"`
 	SortBy     *string  `form:"sort_by,omitempty"` // created_at, rating, title
 	SortOrder  *string  `form:"sort_order,omitempty"` // asc, desc
+	ExcludeIngredients []string `form:"exclude_ingredients,omitempty"`
+	Allergens          []string `form:"allergens,omitempty"` // allergen classes to leave out, see food.AllergenClasses
+	Diets              []string `form:"diets,omitempty"`     // dietary labels recipes must have, see food.DietLabels
+	Status             *string  `form:"status,omitempty"`    // draft, published, archived; only for the user's own recipes
+}
+
+// RecipeSearchResult is a recipe matched by full-text search together with
+// its relevance and highlighted snippets of the matching text.
+type RecipeSearchResult struct {
+	Recipe
+	Rank       float64          `json:"rank"`
+	Highlights RecipeHighlights `json:"highlights"`
+}
+
+// RecipeHighlights are HTML-escaped text with matched terms wrapped in
+// <mark> tags.
+type RecipeHighlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type RecipesByIngredientsRequest struct {
+	Ingredients   []string `json:"ingredients" validate:"required,min=1,max=50,dive,required,max=100"`
+	MustUseAll    bool     `json:"must_use_all"`
+	MaxMissing    *int     `json:"max_missing,omitempty" validate:"omitempty,min=0"`
+	IgnoreStaples bool     `json:"ignore_staples"` // don't count the user's pantry staples as missing
+	Page          int      `json:"page,omitempty" validate:"omitempty,min=1"`
+	Limit         int      `json:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+// RecipeMatch is a recipe found by available ingredients. Coverage is the
+// share of the recipe's ingredients the user has.
+type RecipeMatch struct {
+	Recipe
+	Coverage           float64  `json:"coverage"`
+	MatchedIngredients []string `json:"matched_ingredients"`
+	MissingIngredients []string `json:"missing_ingredients"`
+}
+
+// AutocompleteResponse groups search suggestions by kind.
+type AutocompleteResponse struct {
+	Recipes     []RecipeSuggestion `json:"recipes"`
+	Ingredients []NameSuggestion   `json:"ingredients"`
+	Tags        []NameSuggestion   `json:"tags"`
+}
+
+type RecipeSuggestion struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	ImageURL *string   `json:"image_url,omitempty"`
+	Score    float64   `json:"score"`
+}
+
+// NameSuggestion is an ingredient or tag name with the number of recipes
+// using it.
+type NameSuggestion struct {
+	Name  string  `json:"name"`
+	Count int64   `json:"count"`
+	Score float64 `json:"score"`
 }
 
 func (r *Recipe) BeforeCreate(tx *gorm.DB) error {
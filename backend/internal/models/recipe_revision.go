@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecipeRevision is an immutable snapshot of a recipe, stored every time
+// the recipe is saved. Revisions are numbered from 1 per recipe.
+type RecipeRevision struct {
+	ID           uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RecipeID     uuid.UUID            `json:"recipe_id" gorm:"type:uuid;not null;uniqueIndex:idx_recipe_revisions_number"`
+	Number       int                  `json:"number" gorm:"not null;uniqueIndex:idx_recipe_revisions_number"`
+	UserID       uuid.UUID            `json:"user_id" gorm:"type:uuid;not null"` // who saved it
+	Title        string               `json:"title" gorm:"not null"`
+	RestoredFrom *int                 `json:"restored_from,omitempty"` // revision this one restored
+	Snapshot     *RecipeCreateRequest `json:"snapshot,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+// RecipeDiff lists what changed between two revisions of a recipe.
+type RecipeDiff struct {
+	From         int                 `json:"from"`
+	To           int                 `json:"to"`
+	Fields       []FieldChange       `json:"fields"`
+	Ingredients  []IngredientChange  `json:"ingredients"`
+	Instructions []InstructionChange `json:"instructions"`
+	TagsAdded    []string            `json:"tags_added"`
+	TagsRemoved  []string            `json:"tags_removed"`
+}
+
+// FieldChange is a recipe field, such as "servings" or "nutrition.calories",
+// that has a different value.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// IngredientChange is an ingredient that was added, removed or changed.
+// Ingredients are matched by name.
+type IngredientChange struct {
+	Change string                   `json:"change"` // added, removed, changed
+	Name   string                   `json:"name"`
+	From   *IngredientCreateRequest `json:"from,omitempty"`
+	To     *IngredientCreateRequest `json:"to,omitempty"`
+}
+
+// InstructionChange is a step that was added, removed, reworded or changed
+// otherwise, such as a new timer. Step numbers are those of each revision.
+type InstructionChange struct {
+	Change string                    `json:"change"` // added, removed, reworded, changed
+	From   *InstructionCreateRequest `json:"from,omitempty"`
+	To     *InstructionCreateRequest `json:"to,omitempty"`
+}
+
+func (r *RecipeRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of items a share link can open.
+const (
+	ShareRecipe     = "recipe"
+	ShareCollection = "collection"
+)
+
+// ShareLink grants read-only access to a recipe or collection to anyone
+// with its token, even when the item is private. Only a hash of the token
+// is stored, so the link can be copied once, when it is created.
+type ShareLink struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ResourceType string     `json:"resource_type" gorm:"not null;index:idx_share_links_resource;check:resource_type IN ('recipe','collection')"`
+	ResourceID   uuid.UUID  `json:"resource_id" gorm:"type:uuid;not null;index:idx_share_links_resource"`
+	Label        *string    `json:"label,omitempty"` // reminds the owner who the link was sent to
+	TokenHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ViewCount    int        `json:"view_count" gorm:"default:0"`
+	LastViewedAt *time.Time `json:"last_viewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	// Only returned when the link is created
+	Token string `json:"token,omitempty" gorm:"-"`
+	URL   string `json:"url,omitempty" gorm:"-"`
+}
+
+type ShareLinkCreateRequest struct {
+	Label     *string    `json:"label,omitempty" validate:"omitempty,max=100"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // never expires when left out
+}
+
+// SharedItem is the recipe or collection a share link opens.
+type SharedItem struct {
+	Type       string      `json:"type"`
+	Recipe     *Recipe     `json:"recipe,omitempty"`
+	Collection *Collection `json:"collection,omitempty"`
+}
+
+// IsActive reports whether the link still opens its item.
+func (l *ShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || l.ExpiresAt.After(now))
+}
+
+func (l *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -31,6 +31,19 @@ type ShoppingListItem struct {
 	OrderIndex       int       `json:"order_index" gorm:"default:0"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relationships
+	Sources []ShoppingListItemSource `json:"sources,omitempty" gorm:"foreignKey:ShoppingListItemID;constraint:OnDelete:CASCADE"`
+}
+
+// ShoppingListItemSource records how much of an item a recipe contributed.
+type ShoppingListItemSource struct {
+	ID                 uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ShoppingListItemID uuid.UUID `json:"shopping_list_item_id" gorm:"type:uuid;not null"`
+	RecipeID           uuid.UUID `json:"recipe_id" gorm:"type:uuid;not null"`
+	RecipeTitle        string    `json:"recipe_title"`
+	Amount             *float64  `json:"amount,omitempty"`
+	Unit               *string   `json:"unit,omitempty"`
 }
 
 type ShoppingListCreateRequest struct {
@@ -38,6 +51,16 @@ type ShoppingListCreateRequest struct {
 	Items []ShoppingListItemCreateRequest `json:"items,omitempty"`
 }
 
+type ShoppingListFromRecipesRequest struct {
+	Name    string                  `json:"name" validate:"required,min=1,max=100"`
+	Recipes []RecipeServingsRequest `json:"recipes" validate:"required,min=1,dive"`
+}
+
+type RecipeServingsRequest struct {
+	RecipeID uuid.UUID `json:"recipe_id" validate:"required"`
+	Servings *int      `json:"servings,omitempty" validate:"omitempty,min=1"`
+}
+
 type ShoppingListUpdateRequest struct {
 	Name *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
 }
@@ -82,6 +105,13 @@ func (s *ShoppingListItem) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (s *ShoppingListItemSource) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
 func (s *ShoppingList) ToResponse() ShoppingListResponse {
 	completedCount := 0
 	for _, item := range s.Items {
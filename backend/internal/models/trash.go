@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of deleted items kept in the trash.
+const (
+	TrashRecipe       = "recipe"
+	TrashCollection   = "collection"
+	TrashShoppingList = "shopping-list"
+)
+
+// TrashItem is a deleted recipe, collection or shopping list that can
+// still be restored.
+type TrashItem struct {
+	Type      string     `json:"type"`
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"` // when it is deleted for good
+}
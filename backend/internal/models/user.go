@@ -27,6 +27,19 @@ type User struct {
 	Ratings       []Rating       `json:"ratings,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// PantryStaple is an ingredient a user always has at home and never needs
+// on generated shopping lists.
+type PantryStaple struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_pantry_staples_user_name"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_pantry_staples_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PantryStapleCreateRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
 type UserCreateRequest struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
@@ -66,4 +79,11 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 		u.ID = uuid.New()
 	}
 	return nil
+}
+
+func (p *PantryStaple) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository interface {
+	Create(job *models.ImportJob) error
+	GetByID(id uuid.UUID) (*models.ImportJob, error)
+	GetByUserID(userID uuid.UUID, limit int) ([]models.ImportJob, error)
+	Update(job *models.ImportJob) error
+	FailUnfinished(reason string) (int64, error)
+}
+
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) Create(job *models.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *importJobRepository) GetByID(id uuid.UUID) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := r.db.Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *importJobRepository) GetByUserID(userID uuid.UUID, limit int) ([]models.ImportJob, error) {
+	var jobs []models.ImportJob
+	err := r.db.Omit("results").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *importJobRepository) Update(job *models.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+// FailUnfinished marks jobs that were pending or running when the server
+// stopped as failed, since nothing will pick them up again.
+func (r *importJobRepository) FailUnfinished(reason string) (int64, error) {
+	result := r.db.Model(&models.ImportJob{}).
+		Where("status IN ?", []string{"pending", "running"}).
+		Updates(map[string]interface{}{"status": "failed", "error": reason})
+	return result.RowsAffected, result.Error
+}
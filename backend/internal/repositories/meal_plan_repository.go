@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"time"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MealPlanRepository interface {
+	Create(entry *models.MealPlanEntry) error
+	CreateBatch(entries []models.MealPlanEntry) error
+	GetByID(id uuid.UUID) (*models.MealPlanEntry, error)
+	GetByDateRange(userID uuid.UUID, from, to time.Time) ([]models.MealPlanEntry, error)
+	Update(entry *models.MealPlanEntry) error
+	Delete(id uuid.UUID) error
+	GetFeedToken(tokenHash string) (*models.MealPlanFeedToken, error)
+	SaveFeedToken(token *models.MealPlanFeedToken) error
+	DeleteFeedToken(userID uuid.UUID) error
+}
+
+type mealPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewMealPlanRepository(db *gorm.DB) MealPlanRepository {
+	return &mealPlanRepository{db: db}
+}
+
+func (r *mealPlanRepository) Create(entry *models.MealPlanEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *mealPlanRepository) CreateBatch(entries []models.MealPlanEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Omit("User", "Recipe").Create(&entries).Error
+}
+
+func (r *mealPlanRepository) GetByID(id uuid.UUID) (*models.MealPlanEntry, error) {
+	var entry models.MealPlanEntry
+	err := r.db.Preload("Recipe").
+		Where("id = ?", id).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *mealPlanRepository) GetByDateRange(userID uuid.UUID, from, to time.Time) ([]models.MealPlanEntry, error) {
+	var entries []models.MealPlanEntry
+	err := r.db.Preload("Recipe").
+		Preload("Recipe.Ingredients").
+		Where("user_id = ? AND date BETWEEN ? AND ?", userID, from, to).
+		Order("date ASC, created_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *mealPlanRepository) Update(entry *models.MealPlanEntry) error {
+	return r.db.Omit("User", "Recipe").Save(entry).Error
+}
+
+func (r *mealPlanRepository) Delete(id uuid.UUID) error {
+	return r.db.Where("id = ?", id).Delete(&models.MealPlanEntry{}).Error
+}
+
+func (r *mealPlanRepository) GetFeedToken(tokenHash string) (*models.MealPlanFeedToken, error) {
+	var token models.MealPlanFeedToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *mealPlanRepository) SaveFeedToken(token *models.MealPlanFeedToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// A user has at most one feed token, so issuing one revokes the previous
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&models.MealPlanFeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *mealPlanRepository) DeleteFeedToken(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MealPlanFeedToken{}).Error
+}
@@ -1,28 +1,44 @@
 package repositories
 
 import (
+	"encoding/json"
 	"fmt"
+	"html"
+	"regexp"
 	"strings"
+	"time"
+	"yummio-backend/internal/food"
 	"yummio-backend/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RecipeRepository interface {
-	Create(recipe *models.Recipe) error
+	Create(recipe *models.Recipe, revision *models.RecipeRevision) error
 	GetByID(id uuid.UUID) (*models.Recipe, error)
 	GetByUserID(userID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error)
 	GetAll(query *models.RecipeQuery) ([]models.Recipe, int64, error)
-	Update(recipe *models.Recipe) error
+	Update(recipe *models.Recipe, base, revision *models.RecipeRevision) error
 	Delete(id uuid.UUID) error
-	Search(query *models.RecipeQuery) ([]models.Recipe, int64, error)
-	GetFeatured(limit int) ([]models.Recipe, error)
+	Search(query *models.RecipeQuery) ([]models.RecipeSearchResult, int64, error)
+	Autocomplete(term string, limit int) (*models.AutocompleteResponse, error)
+	FindByIngredients(names []string, matchAll bool, limit int) ([]models.Recipe, error)
+	GetFeatured(limit int, query *models.RecipeQuery) ([]models.Recipe, error)
+	FindDuplicate(userID uuid.UUID, title string, sourceURL *string) (*models.Recipe, error)
+	GetWithoutDietaryLabels(limit int) ([]models.Recipe, error)
+	UpdateDietaryLabels(recipe *models.Recipe) error
+	GetUsingSubRecipe(subRecipeID uuid.UUID) ([]uuid.UUID, error)
+	UpdateDerived(recipe *models.Recipe) error
 	AddToFavorites(userID, recipeID uuid.UUID) error
 	RemoveFromFavorites(userID, recipeID uuid.UUID) error
 	GetFavorites(userID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error)
 	RateRecipe(rating *models.Rating) error
 	UpdateRating(recipeID uuid.UUID) error
+	GetForks(recipeID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error)
+	UpdateForkCount(recipeID uuid.UUID) error
+	PublishScheduled(now time.Time) (int64, error)
 }
 
 type recipeRepository struct {
@@ -33,7 +49,9 @@ func NewRecipeRepository(db *gorm.DB) RecipeRepository {
 	return &recipeRepository{db: db}
 }
 
-func (r *recipeRepository) Create(recipe *models.Recipe) error {
+// Create saves a new recipe and stores revision as its first revision, in
+// one transaction.
+func (r *recipeRepository) Create(recipe *models.Recipe, revision *models.RecipeRevision) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(recipe).Error; err != nil {
 			return err
@@ -65,7 +83,8 @@ func (r *recipeRepository) Create(recipe *models.Recipe) error {
 			}
 		}
 
-		return nil
+		revision.RecipeID = recipe.ID
+		return createRevision(tx, revision)
 	})
 }
 
@@ -86,16 +105,32 @@ func (r *recipeRepository) GetByID(id uuid.UUID) (*models.Recipe, error) {
 
 func (r *recipeRepository) GetByUserID(userID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error) {
 	db := r.db.Model(&models.Recipe{}).Where("user_id = ?", userID)
+	if query.Status != nil && *query.Status != "" {
+		db = db.Where("status = ?", *query.Status)
+	}
+	return r.queryRecipes(db, query)
+}
+
+// GetForks lists the public recipes forked from a recipe.
+func (r *recipeRepository) GetForks(recipeID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error) {
+	db := r.db.Model(&models.Recipe{}).Where("forked_from_id = ? AND is_public = ? AND status = ?", recipeID, true, models.RecipeStatusPublished)
 	return r.queryRecipes(db, query)
 }
 
 func (r *recipeRepository) GetAll(query *models.RecipeQuery) ([]models.Recipe, int64, error) {
-	db := r.db.Model(&models.Recipe{}).Where("is_public = ?", true)
+	db := r.db.Model(&models.Recipe{}).Where("is_public = ? AND status = ?", true, models.RecipeStatusPublished)
 	return r.queryRecipes(db, query)
 }
 
-func (r *recipeRepository) Update(recipe *models.Recipe) error {
+// Update saves a recipe and stores revision as its next revision, in one
+// transaction. base is the recipe as it was, stored first if the recipe has
+// no revisions yet because it was saved before revisions were kept.
+func (r *recipeRepository) Update(recipe *models.Recipe, base, revision *models.RecipeRevision) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := createBaseRevision(tx, base); err != nil {
+			return err
+		}
+
 		// Update recipe
 		if err := tx.Save(recipe).Error; err != nil {
 			return err
@@ -131,7 +166,7 @@ func (r *recipeRepository) Update(recipe *models.Recipe) error {
 			}
 		}
 
-		return nil
+		return createRevision(tx, revision)
 	})
 }
 
@@ -139,28 +174,376 @@ func (r *recipeRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Recipe{}, id).Error
 }
 
-func (r *recipeRepository) Search(query *models.RecipeQuery) ([]models.Recipe, int64, error) {
-	db := r.db.Model(&models.Recipe{}).Where("is_public = ?", true)
+// ts_headline marks matches with control characters rather than tags, so
+// the text around them can be HTML-escaped before they become <mark> tags.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// Options for ts_headline when highlighting search matches
+const (
+	titleHeadlineOptions       = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	descriptionHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MinWords=15, MaxWords=35, MaxFragments=2"
+)
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// highlight turns a ts_headline snippet into HTML: the recipe's own text is
+// escaped and only the matches are wrapped in <mark> tags.
+func highlight(snippet string) string {
+	return highlightMarks.Replace(html.EscapeString(snippet))
+}
+
+// fuzzyMatchThreshold is the pg_trgm word similarity a misspelt term needs
+// to match, low enough for "lasange" to find "lasagne".
+const fuzzyMatchThreshold = 0.4
 
-	if query.Search != nil && *query.Search != "" {
-		searchTerm := "%" + strings.ToLower(*query.Search) + "%"
-		db = db.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+// searchHit is a matched recipe before its details are loaded.
+type searchHit struct {
+	ID                   uuid.UUID
+	Rank                 float64
+	TitleHighlight       string
+	DescriptionHighlight string
+}
+
+func (r *recipeRepository) Search(query *models.RecipeQuery) ([]models.RecipeSearchResult, int64, error) {
+	db := r.db.Model(&models.Recipe{}).Where("is_public = ? AND status = ?", true, models.RecipeStatusPublished)
+
+	if query.Search == nil || strings.TrimSpace(*query.Search) == "" {
+		recipes, total, err := r.queryRecipes(db, query)
+		if err != nil {
+			return nil, 0, err
+		}
+		results := make([]models.RecipeSearchResult, len(recipes))
+		for i, recipe := range recipes {
+			results[i] = models.RecipeSearchResult{
+				Recipe:     recipe,
+				Highlights: models.RecipeHighlights{Title: html.EscapeString(recipe.Title)},
+			}
+		}
+		return results, total, nil
 	}
 
-	return r.queryRecipes(db, query)
+	tsQuery, args := buildTSQuery(*query.Search)
+	if tsQuery == "" {
+		return []models.RecipeSearchResult{}, 0, nil
+	}
+
+	db = applyRecipeFilters(db.Where("recipes.search_vector @@ "+tsQuery, args...), query)
+
+	// Count total records
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Nothing matched exactly, so the query is probably misspelt
+	if total == 0 {
+		return r.fuzzySearch(query)
+	}
+
+	// Most relevant first unless another order was asked for
+	orderClause := "rank DESC, recipes.rating DESC"
+	if query.SortBy != nil && *query.SortBy != "" {
+		orderClause = recipeOrder(query)
+	}
+
+	selectClause := fmt.Sprintf(
+		"recipes.id, ts_rank(recipes.search_vector, %[1]s) AS rank, "+
+			"ts_headline('english', recipes.title, %[1]s, '%[2]s') AS title_highlight, "+
+			"ts_headline('english', COALESCE(recipes.description, ''), %[1]s, '%[3]s') AS description_highlight",
+		tsQuery, titleHeadlineOptions, descriptionHeadlineOptions,
+	)
+	var selectArgs []interface{}
+	for i := 0; i < 3; i++ {
+		selectArgs = append(selectArgs, args...)
+	}
+
+	var hits []searchHit
+	err := db.Select(selectClause, selectArgs...).
+		Order(orderClause).
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, err := r.loadSearchHits(hits)
+	return results, total, err
+}
+
+// fuzzySearch matches recipe titles by trigram similarity so that
+// misspellings still find results.
+func (r *recipeRepository) fuzzySearch(query *models.RecipeQuery) ([]models.RecipeSearchResult, int64, error) {
+	term := fuzzyTerm(*query.Search)
+	if term == "" {
+		return []models.RecipeSearchResult{}, 0, nil
+	}
+
+	var total int64
+	var hits []searchHit
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := setFuzzyThreshold(tx); err != nil {
+			return err
+		}
+
+		db := tx.Model(&models.Recipe{}).
+			Where("is_public = ? AND status = ?", true, models.RecipeStatusPublished).
+			Where("? <% lower(recipes.title)", term)
+		db = applyRecipeFilters(db, query)
+
+		if err := db.Count(&total).Error; err != nil {
+			return err
+		}
+
+		orderClause := "rank DESC, recipes.rating DESC"
+		if query.SortBy != nil && *query.SortBy != "" {
+			orderClause = recipeOrder(query)
+		}
+
+		return db.Select("recipes.id, word_similarity(?, lower(recipes.title)) AS rank, recipes.title AS title_highlight", term).
+			Order(orderClause).
+			Offset((query.Page - 1) * query.Limit).
+			Limit(query.Limit).
+			Scan(&hits).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, err := r.loadSearchHits(hits)
+	return results, total, err
+}
+
+// loadSearchHits loads the recipes for search hits, keeping their order.
+func (r *recipeRepository) loadSearchHits(hits []searchHit) ([]models.RecipeSearchResult, error) {
+	if len(hits) == 0 {
+		return []models.RecipeSearchResult{}, nil
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var recipes []models.Recipe
+	if err := r.db.Preload("User").Preload("Tags").Where("id IN ?", ids).Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Recipe, len(recipes))
+	for _, recipe := range recipes {
+		byID[recipe.ID] = recipe
+	}
+
+	results := make([]models.RecipeSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		recipe, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		result := models.RecipeSearchResult{
+			Recipe:     recipe,
+			Rank:       hit.Rank,
+			Highlights: models.RecipeHighlights{Title: highlight(hit.TitleHighlight)},
+		}
+		if recipe.Description != nil && *recipe.Description != "" {
+			result.Highlights.Description = highlight(hit.DescriptionHighlight)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r *recipeRepository) Autocomplete(term string, limit int) (*models.AutocompleteResponse, error) {
+	response := &models.AutocompleteResponse{
+		Recipes:     []models.RecipeSuggestion{},
+		Ingredients: []models.NameSuggestion{},
+		Tags:        []models.NameSuggestion{},
+	}
+
+	// fuzzyTerm leaves only letters, digits and spaces, so no LIKE wildcards
+	term = fuzzyTerm(term)
+	if term == "" {
+		return response, nil
+	}
+	prefix := term + "%"
+	contains := "%" + term + "%"
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := setFuzzyThreshold(tx); err != nil {
+			return err
+		}
+
+		// Prefix matches first, then the closest fuzzy matches
+		err := tx.Raw(`
+			SELECT id, title, image_url, word_similarity(?, lower(title)) AS score
+			FROM recipes
+			WHERE is_public = true AND status = 'published' AND deleted_at IS NULL
+				AND (? <% lower(title) OR lower(title) LIKE ?)
+			ORDER BY lower(title) LIKE ? DESC, score DESC, rating DESC
+			LIMIT ?`,
+			term, term, contains, prefix, limit,
+		).Scan(&response.Recipes).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Raw(`
+			SELECT lower(i.name) AS name, COUNT(DISTINCT i.recipe_id) AS count,
+				MAX(word_similarity(?, lower(i.name))) AS score
+			FROM ingredients i
+			JOIN recipes r ON r.id = i.recipe_id
+			WHERE r.is_public = true AND r.status = 'published' AND r.deleted_at IS NULL
+				AND (? <% lower(i.name) OR lower(i.name) LIKE ?)
+			GROUP BY lower(i.name)
+			ORDER BY bool_or(lower(i.name) LIKE ?) DESC, score DESC, count DESC
+			LIMIT ?`,
+			term, term, contains, prefix, limit,
+		).Scan(&response.Ingredients).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Raw(`
+			SELECT t.name, COUNT(DISTINCT r.id) AS count, word_similarity(?, lower(t.name)) AS score
+			FROM tags t
+			JOIN recipe_tags rt ON rt.tag_id = t.id
+			JOIN recipes r ON r.id = rt.recipe_id
+			WHERE r.is_public = true AND r.status = 'published' AND r.deleted_at IS NULL
+				AND (? <% lower(t.name) OR lower(t.name) LIKE ?)
+			GROUP BY t.id, t.name
+			ORDER BY lower(t.name) LIKE ? DESC, score DESC, count DESC
+			LIMIT ?`,
+			term, term, contains, prefix, limit,
+		).Scan(&response.Tags).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
 }
 
-func (r *recipeRepository) GetFeatured(limit int) ([]models.Recipe, error) {
+// FindByIngredients returns public recipes with an ingredient containing
+// any (or, with matchAll, every) of the names, most matching first. Names
+// match as whole words, singular or plural, so the normalized "cherry"
+// finds "cherries".
+func (r *recipeRepository) FindByIngredients(names []string, matchAll bool, limit int) ([]models.Recipe, error) {
 	var recipes []models.Recipe
+	if len(names) == 0 {
+		return recipes, nil
+	}
+
+	conditions := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		conditions[i] = "EXISTS (SELECT 1 FROM ingredients WHERE ingredients.recipe_id = recipes.id AND lower(ingredients.name) ~ ?)"
+		args[i] = food.SQLTermPattern(name)
+	}
+
+	joiner := " OR "
+	if matchAll {
+		joiner = " AND "
+	}
+
+	matchCount := "(" + strings.Join(conditions, ")::int + (") + ")::int"
+
 	err := r.db.Preload("User").
 		Preload("Tags").
-		Where("is_public = ? AND rating >= ?", true, 4.0).
+		Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_index ASC")
+		}).
+		Where("is_public = ? AND status = ?", true, models.RecipeStatusPublished).
+		Where("("+strings.Join(conditions, joiner)+")", args...).
+		Order(clause.Expr{SQL: matchCount + " DESC", Vars: args, WithoutParentheses: true}).
+		Order("rating DESC").
+		Limit(limit).
+		Find(&recipes).Error
+	return recipes, err
+}
+
+func (r *recipeRepository) GetFeatured(limit int, query *models.RecipeQuery) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	db := applyRecipeFilters(r.db.Model(&models.Recipe{}), query)
+	err := db.Preload("User").
+		Preload("Tags").
+		Where("is_public = ? AND status = ? AND rating >= ?", true, models.RecipeStatusPublished, 4.0).
 		Order("rating DESC, rating_count DESC").
 		Limit(limit).
 		Find(&recipes).Error
 	return recipes, err
 }
 
+// FindDuplicate returns a recipe of the user with the same title, ignoring
+// case, or imported from the same page, or nil if there is none.
+func (r *recipeRepository) FindDuplicate(userID uuid.UUID, title string, sourceURL *string) (*models.Recipe, error) {
+	db := r.db.Where("user_id = ?", userID)
+	if sourceURL != nil {
+		db = db.Where("(LOWER(title) = LOWER(?) OR source_url = ?)", title, *sourceURL)
+	} else {
+		db = db.Where("LOWER(title) = LOWER(?)", title)
+	}
+
+	var recipes []models.Recipe
+	if err := db.Limit(1).Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, nil
+	}
+	return &recipes[0], nil
+}
+
+// GetWithoutDietaryLabels returns recipes saved before dietary labels or
+// allergens were derived, with their ingredients.
+func (r *recipeRepository) GetWithoutDietaryLabels(limit int) ([]models.Recipe, error) {
+	var recipes []models.Recipe
+	err := r.db.Preload("Ingredients").
+		Where("dietary_labels IS NULL OR allergens IS NULL").
+		Limit(limit).
+		Find(&recipes).Error
+	return recipes, err
+}
+
+func (r *recipeRepository) UpdateDietaryLabels(recipe *models.Recipe) error {
+	return r.db.Model(recipe).
+		Select("dietary_labels", "dietary_reasons", "allergens").
+		UpdateColumns(recipe).Error
+}
+
+// GetUsingSubRecipe returns the IDs of the recipes with the given recipe
+// among their ingredients.
+func (r *recipeRepository) GetUsingSubRecipe(subRecipeID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.Recipe{}).
+		Where("EXISTS (SELECT 1 FROM ingredients WHERE ingredients.recipe_id = recipes.id AND ingredients.sub_recipe_id = ?)", subRecipeID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateDerived saves what is derived from a recipe's ingredients, its
+// dietary labels, allergens and calculated nutrition, without touching the
+// recipe itself.
+func (r *recipeRepository) UpdateDerived(recipe *models.Recipe) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(recipe).
+			Select("dietary_labels", "dietary_reasons", "allergens").
+			UpdateColumns(recipe).Error
+		if err != nil {
+			return err
+		}
+
+		if recipe.Nutrition != nil && recipe.Nutrition.Calculated {
+			recipe.Nutrition.RecipeID = recipe.ID
+			return tx.Save(recipe.Nutrition).Error
+		}
+		return nil
+	})
+}
+
 func (r *recipeRepository) AddToFavorites(userID, recipeID uuid.UUID) error {
 	return r.db.Exec("INSERT INTO user_favorites (user_id, recipe_id) VALUES (?, ?) ON CONFLICT DO NOTHING", userID, recipeID).Error
 }
@@ -229,7 +612,46 @@ func (r *recipeRepository) UpdateRating(recipeID uuid.UUID) error {
 }
 
 func (r *recipeRepository) queryRecipes(db *gorm.DB, query *models.RecipeQuery) ([]models.Recipe, int64, error) {
-	// Apply filters
+	db = applyRecipeFilters(db, query)
+
+	// Count total records
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (query.Page - 1) * query.Limit
+
+	var recipes []models.Recipe
+	err := db.Preload("User").
+		Preload("Tags").
+		Order(recipeOrder(query)).
+		Offset(offset).
+		Limit(query.Limit).
+		Find(&recipes).Error
+
+	return recipes, total, err
+}
+
+// usedIngredientsSQL selects the names of the ingredients of the recipe in
+// the enclosing query and of the sub-recipes it uses, following the same
+// rules as the services: sub-recipes must not be deleted and must belong to
+// the recipe's owner or be listed, and they nest at most 5 levels deep.
+const usedIngredientsSQL = `
+	WITH RECURSIVE used(id, owner_id, depth) AS (
+		SELECT recipes.id, recipes.user_id, 1
+		UNION
+		SELECT sub.id, used.owner_id, used.depth + 1
+		FROM used
+		JOIN ingredients link ON link.recipe_id = used.id
+		JOIN recipes sub ON sub.id = link.sub_recipe_id
+		WHERE used.depth < 5 AND sub.deleted_at IS NULL
+			AND (sub.user_id = used.owner_id OR (sub.is_public = true AND sub.status = 'published'))
+	)
+	SELECT ingredients.name FROM used JOIN ingredients ON ingredients.recipe_id = used.id`
+
+func applyRecipeFilters(db *gorm.DB, query *models.RecipeQuery) *gorm.DB {
 	if query.Difficulty != nil && *query.Difficulty != "" {
 		db = db.Where("difficulty = ?", *query.Difficulty)
 	}
@@ -238,6 +660,27 @@ func (r *recipeRepository) queryRecipes(db *gorm.DB, query *models.RecipeQuery)
 		db = db.Where("type = ?", *query.Type)
 	}
 
+	// Leave out recipes with an ingredient the user can't or won't eat,
+	// including the ingredients of their sub-recipes
+	for _, name := range query.ExcludeIngredients {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		db = db.Where("NOT EXISTS (SELECT 1 FROM ("+usedIngredientsSQL+") used_ingredients WHERE lower(used_ingredients.name) ~ ?)", food.SQLTermPattern(name))
+	}
+
+	// Allergens are stored with sub-recipes expanded. Recipes whose
+	// allergens haven't been derived yet are left out as well
+	for _, class := range query.Allergens {
+		classJSON, _ := json.Marshal([]string{class})
+		db = db.Where("recipes.allergens IS NOT NULL AND NOT recipes.allergens @> ?::jsonb", string(classJSON))
+	}
+
+	for _, label := range query.Diets {
+		labelJSON, _ := json.Marshal([]string{label})
+		db = db.Where("recipes.dietary_labels @> ?::jsonb", string(labelJSON))
+	}
+
 	if len(query.Tags) > 0 {
 		db = db.Joins("JOIN recipe_tags ON recipes.id = recipe_tags.recipe_id").
 			Joins("JOIN tags ON recipe_tags.tag_id = tags.id").
@@ -245,13 +688,10 @@ func (r *recipeRepository) queryRecipes(db *gorm.DB, query *models.RecipeQuery)
 			Group("recipes.id")
 	}
 
-	// Count total records
-	var total int64
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+	return db
+}
 
-	// Apply sorting
+func recipeOrder(query *models.RecipeQuery) string {
 	sortBy := "created_at"
 	if query.SortBy != nil && *query.SortBy != "" {
 		sortBy = *query.SortBy
@@ -262,18 +702,84 @@ func (r *recipeRepository) queryRecipes(db *gorm.DB, query *models.RecipeQuery)
 		sortOrder = strings.ToUpper(*query.SortOrder)
 	}
 
-	orderClause := fmt.Sprintf("%s %s", sortBy, sortOrder)
+	return fmt.Sprintf("%s %s", sortBy, sortOrder)
+}
 
-	// Apply pagination
-	offset := (query.Page - 1) * query.Limit
+var (
+	// searchTermPattern splits a search into "quoted phrases" and single terms
+	searchTermPattern = regexp.MustCompile(`"([^"]*)"|(\S+)`)
+	nonWordPattern    = regexp.MustCompile(`[^\pL\pN]+`)
+)
 
-	var recipes []models.Recipe
-	err := db.Preload("User").
-		Preload("Tags").
-		Order(orderClause).
-		Offset(offset).
-		Limit(query.Limit).
-		Find(&recipes).Error
+// buildTSQuery turns a search string into a tsquery SQL expression and its
+// arguments. Quoted text matches as a phrase, a trailing * matches prefixes
+// and all other terms must appear somewhere in the recipe.
+func buildTSQuery(search string) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+
+	for _, match := range searchTermPattern.FindAllStringSubmatch(search, -1) {
+		phrase, term := strings.TrimSpace(match[1]), match[2]
+		switch {
+		case phrase != "":
+			parts = append(parts, "phraseto_tsquery('english', ?)")
+			args = append(args, phrase)
+		case strings.HasSuffix(term, "*"):
+			// to_tsquery parses its input, so only pass it plain words
+			words := strings.Fields(nonWordPattern.ReplaceAllString(term, " "))
+			if len(words) == 0 {
+				continue
+			}
+			words[len(words)-1] += ":*"
+			parts = append(parts, "to_tsquery('english', ?)")
+			args = append(args, strings.Join(words, " & "))
+		case term != "":
+			parts = append(parts, "plainto_tsquery('english', ?)")
+			args = append(args, term)
+		}
+	}
 
-	return recipes, total, err
-}
\ No newline at end of file
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(parts, " && ") + ")", args
+}
+
+// fuzzyTerm reduces a search to lowercase words for trigram matching.
+func fuzzyTerm(search string) string {
+	return strings.Join(strings.Fields(nonWordPattern.ReplaceAllString(strings.ToLower(search), " ")), " ")
+}
+
+// setFuzzyThreshold lowers the pg_trgm <% threshold for the rest of the
+// transaction.
+func setFuzzyThreshold(tx *gorm.DB) error {
+	return tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", fuzzyMatchThreshold)).Error
+}
+
+// UpdateForkCount recounts the forks of a recipe, public or not.
+func (r *recipeRepository) UpdateForkCount(recipeID uuid.UUID) error {
+	var count int64
+	if err := r.db.Model(&models.Recipe{}).
+		Where("forked_from_id = ?", recipeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+
+	return r.db.Model(&models.Recipe{}).
+		Where("id = ?", recipeID).
+		UpdateColumn("fork_count", count).Error
+}
+
+// PublishScheduled publishes the drafts scheduled at or before now and makes
+// them public, returning how many there were.
+func (r *recipeRepository) PublishScheduled(now time.Time) (int64, error) {
+	result := r.db.Model(&models.Recipe{}).
+		Where("status = ? AND publish_at <= ?", models.RecipeStatusDraft, now).
+		UpdateColumns(map[string]interface{}{
+			"status":     models.RecipeStatusPublished,
+			"is_public":  true,
+			"publish_at": nil,
+			"updated_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		snippet string
+		want    string
+	}{
+		{"Tomato \x02soup\x03", "Tomato <mark>soup</mark>"},
+		{"<img src=x onerror=alert(1)> \x02soup\x03", "&lt;img src=x onerror=alert(1)&gt; <mark>soup</mark>"},
+		{"Mac & \x02cheese\x03 \"deluxe\"", "Mac &amp; <mark>cheese</mark> &#34;deluxe&#34;"},
+		{"<mark>fake</mark>", "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+
+	for _, tt := range tests {
+		if got := highlight(tt.snippet); got != tt.want {
+			t.Errorf("highlight(%q) = %q, want %q", tt.snippet, got, tt.want)
+		}
+	}
+}
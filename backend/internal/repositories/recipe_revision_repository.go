@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecipeRevisionRepository reads and prunes the revisions that
+// RecipeRepository stores as recipes are saved.
+type RecipeRevisionRepository interface {
+	GetByRecipeID(recipeID uuid.UUID) ([]models.RecipeRevision, error)
+	GetByNumber(recipeID uuid.UUID, number int) (*models.RecipeRevision, error)
+	Prune(recipeID uuid.UUID, keep int) error
+}
+
+type recipeRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewRecipeRevisionRepository(db *gorm.DB) RecipeRevisionRepository {
+	return &recipeRevisionRepository{db: db}
+}
+
+// lockRevisions locks a recipe's row until tx ends, so concurrent saves of
+// the recipe take turns numbering their revisions.
+func lockRevisions(tx *gorm.DB, recipeID uuid.UUID) error {
+	return tx.Exec("SELECT id FROM recipes WHERE id = ? FOR UPDATE", recipeID).Error
+}
+
+// createRevision stores a revision numbered after the recipe's latest one,
+// as part of the transaction saving the recipe.
+func createRevision(tx *gorm.DB, revision *models.RecipeRevision) error {
+	if err := lockRevisions(tx, revision.RecipeID); err != nil {
+		return err
+	}
+
+	var latest int
+	err := tx.Model(&models.RecipeRevision{}).
+		Where("recipe_id = ?", revision.RecipeID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	revision.Number = latest + 1
+	return tx.Create(revision).Error
+}
+
+// createBaseRevision stores a revision only if the recipe has none yet.
+func createBaseRevision(tx *gorm.DB, revision *models.RecipeRevision) error {
+	if err := lockRevisions(tx, revision.RecipeID); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(&models.RecipeRevision{}).Where("recipe_id = ?", revision.RecipeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return createRevision(tx, revision)
+}
+
+// GetByRecipeID lists a recipe's revisions, newest first, without their
+// snapshots.
+func (r *recipeRevisionRepository) GetByRecipeID(recipeID uuid.UUID) ([]models.RecipeRevision, error) {
+	var revisions []models.RecipeRevision
+	err := r.db.Omit("snapshot").
+		Where("recipe_id = ?", recipeID).
+		Order("number DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *recipeRevisionRepository) GetByNumber(recipeID uuid.UUID, number int) (*models.RecipeRevision, error) {
+	var revision models.RecipeRevision
+	err := r.db.Where("recipe_id = ? AND number = ?", recipeID, number).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// Prune deletes all but the newest keep revisions of a recipe.
+func (r *recipeRevisionRepository) Prune(recipeID uuid.UUID, keep int) error {
+	return r.db.Where("recipe_id = ? AND number <= (SELECT MAX(number) FROM recipe_revisions WHERE recipe_id = ?) - ?",
+		recipeID, recipeID, keep).
+		Delete(&models.RecipeRevision{}).Error
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"time"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ShareLinkRepository interface {
+	Create(link *models.ShareLink) error
+	GetByID(id uuid.UUID) (*models.ShareLink, error)
+	GetByTokenHash(tokenHash string) (*models.ShareLink, error)
+	GetActiveByUserID(userID uuid.UUID) ([]models.ShareLink, error)
+	GetActiveByResource(resourceType string, resourceID uuid.UUID) ([]models.ShareLink, error)
+	Revoke(id uuid.UUID) error
+	RecordView(id uuid.UUID) error
+}
+
+type shareLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewShareLinkRepository(db *gorm.DB) ShareLinkRepository {
+	return &shareLinkRepository{db: db}
+}
+
+func (r *shareLinkRepository) Create(link *models.ShareLink) error {
+	return r.db.Create(link).Error
+}
+
+func (r *shareLinkRepository) GetByID(id uuid.UUID) (*models.ShareLink, error) {
+	var link models.ShareLink
+	if err := r.db.Where("id = ?", id).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *shareLinkRepository) GetByTokenHash(tokenHash string) (*models.ShareLink, error) {
+	var link models.ShareLink
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetActiveByUserID lists a user's links that are neither revoked nor
+// expired, newest first.
+func (r *shareLinkRepository) GetActiveByUserID(userID uuid.UUID) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	err := r.active().
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// GetActiveByResource lists the links to an item that are neither revoked
+// nor expired, newest first.
+func (r *shareLinkRepository) GetActiveByResource(resourceType string, resourceID uuid.UUID) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	err := r.active().
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Order("created_at DESC").
+		Find(&links).Error
+	return links, err
+}
+
+func (r *shareLinkRepository) active() *gorm.DB {
+	return r.db.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
+
+func (r *shareLinkRepository) Revoke(id uuid.UUID) error {
+	return r.db.Model(&models.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", time.Now()).Error
+}
+
+// RecordView counts a visit to a link.
+func (r *shareLinkRepository) RecordView(id uuid.UUID) error {
+	return r.db.Model(&models.ShareLink{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": time.Now(),
+		}).Error
+}
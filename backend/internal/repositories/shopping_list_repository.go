@@ -17,6 +17,7 @@ type ShoppingListRepository interface {
 	UpdateItem(item *models.ShoppingListItem) error
 	DeleteItem(id uuid.UUID) error
 	GetItem(id uuid.UUID) (*models.ShoppingListItem, error)
+	SaveItems(items []models.ShoppingListItem, deleteIDs []uuid.UUID) error
 }
 
 type shoppingListRepository struct {
@@ -33,7 +34,7 @@ func (r *shoppingListRepository) Create(list *models.ShoppingList) error {
 
 func (r *shoppingListRepository) GetByID(id uuid.UUID) (*models.ShoppingList, error) {
 	var list models.ShoppingList
-	err := r.db.Preload("Items").
+	err := r.db.Preload("Items.Sources").
 		Where("id = ?", id).
 		First(&list).Error
 	if err != nil {
@@ -73,9 +74,38 @@ func (r *shoppingListRepository) DeleteItem(id uuid.UUID) error {
 
 func (r *shoppingListRepository) GetItem(id uuid.UUID) (*models.ShoppingListItem, error) {
 	var item models.ShoppingListItem
-	err := r.db.Where("id = ?", id).First(&item).Error
+	err := r.db.Preload("Sources").Where("id = ?", id).First(&item).Error
 	if err != nil {
 		return nil, err
 	}
 	return &item, nil
+}
+
+func (r *shoppingListRepository) SaveItems(items []models.ShoppingListItem, deleteIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(deleteIDs) > 0 {
+			if err := tx.Where("id IN ?", deleteIDs).Delete(&models.ShoppingListItem{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, item := range items {
+			if err := tx.Omit("Sources").Save(&item).Error; err != nil {
+				return err
+			}
+
+			// Replace sources
+			if err := tx.Where("shopping_list_item_id = ?", item.ID).Delete(&models.ShoppingListItemSource{}).Error; err != nil {
+				return err
+			}
+			for _, source := range item.Sources {
+				source.ShoppingListItemID = item.ID
+				if err := tx.Create(&source).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
 }
\ No newline at end of file
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrashRepository reaches the soft-deleted recipes, collections and
+// shopping lists that other repositories no longer see.
+type TrashRepository interface {
+	GetByUserID(userID uuid.UUID) ([]models.TrashItem, error)
+	GetOwner(itemType string, id uuid.UUID) (uuid.UUID, error)
+	Restore(itemType string, id uuid.UUID) error
+	Purge(itemType string, id uuid.UUID) error
+	PurgeDeletedBefore(cutoff time.Time) (int64, error)
+}
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// trashTables maps each kind of trash item to its model and the column
+// holding its name.
+var trashTables = []struct {
+	itemType   string
+	model      interface{}
+	nameColumn string
+}{
+	{models.TrashRecipe, &models.Recipe{}, "title"},
+	{models.TrashCollection, &models.Collection{}, "name"},
+	{models.TrashShoppingList, &models.ShoppingList{}, "name"},
+}
+
+var errUnknownTrashType = errors.New("unknown trash type")
+
+func trashModel(itemType string) (interface{}, error) {
+	for _, table := range trashTables {
+		if table.itemType == itemType {
+			return table.model, nil
+		}
+	}
+	return nil, errUnknownTrashType
+}
+
+// deleted scopes a query to soft-deleted rows.
+func (r *trashRepository) deleted(model interface{}) *gorm.DB {
+	return r.db.Unscoped().Model(model).Where("deleted_at IS NOT NULL")
+}
+
+// GetByUserID lists a user's deleted items, most recently deleted first.
+func (r *trashRepository) GetByUserID(userID uuid.UUID) ([]models.TrashItem, error) {
+	items := []models.TrashItem{}
+	for _, table := range trashTables {
+		var found []models.TrashItem
+		err := r.deleted(table.model).
+			Select("id, "+table.nameColumn+" AS name, deleted_at").
+			Where("user_id = ?", userID).
+			Scan(&found).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range found {
+			item.Type = table.itemType
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// GetOwner returns the user a deleted item belongs to.
+func (r *trashRepository) GetOwner(itemType string, id uuid.UUID) (uuid.UUID, error) {
+	model, err := trashModel(itemType)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var owners []uuid.UUID
+	if err := r.deleted(model).Where("id = ?", id).Pluck("user_id", &owners).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if len(owners) == 0 {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	return owners[0], nil
+}
+
+func (r *trashRepository) Restore(itemType string, id uuid.UUID) error {
+	model, err := trashModel(itemType)
+	if err != nil {
+		return err
+	}
+
+	result := r.deleted(model).Where("id = ?", id).UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Purge deletes a trashed item for good, along with everything that only
+// exists for it.
+func (r *trashRepository) Purge(itemType string, id uuid.UUID) error {
+	model, err := trashModel(itemType)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var dependents []*gorm.DB
+		switch itemType {
+		case models.TrashRecipe:
+			dependents = []*gorm.DB{
+				tx.Exec("DELETE FROM recipe_tags WHERE recipe_id = ?", id),
+				tx.Exec("DELETE FROM collection_recipes WHERE recipe_id = ?", id),
+				tx.Exec("DELETE FROM user_favorites WHERE recipe_id = ?", id),
+				tx.Where("recipe_id = ?", id).Delete(&models.Ingredient{}),
+				tx.Where("recipe_id = ?", id).Delete(&models.Instruction{}),
+				tx.Where("recipe_id = ?", id).Delete(&models.Nutrition{}),
+				tx.Where("recipe_id = ?", id).Delete(&models.Rating{}),
+				tx.Where("recipe_id = ?", id).Delete(&models.MealPlanEntry{}),
+				tx.Where("recipe_id = ?", id).Delete(&models.RecipeRevision{}),
+				tx.Where("resource_type = ? AND resource_id = ?", models.ShareRecipe, id).Delete(&models.ShareLink{}),
+				// Recipes using it as a sub-recipe keep it as a plain ingredient
+				tx.Model(&models.Ingredient{}).Where("sub_recipe_id = ?", id).UpdateColumn("sub_recipe_id", nil),
+			}
+		case models.TrashCollection:
+			dependents = []*gorm.DB{
+				tx.Exec("DELETE FROM collection_recipes WHERE collection_id = ?", id),
+				tx.Where("resource_type = ? AND resource_id = ?", models.ShareCollection, id).Delete(&models.ShareLink{}),
+			}
+		}
+		for _, result := range dependents {
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		// Shopping list items go with their list through the foreign key
+		result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// purgeBatchSize bounds how many items of each kind are looked up at once
+// when emptying old trash.
+const purgeBatchSize = 100
+
+// PurgeDeletedBefore purges every item deleted before cutoff and returns
+// how many were purged. Items that fail to purge are logged and skipped,
+// so one of them can't keep the rest from expiring.
+func (r *trashRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	var purged int64
+	for _, table := range trashTables {
+		after := uuid.Nil
+		for {
+			var ids []uuid.UUID
+			err := r.deleted(table.model).
+				Where("deleted_at < ? AND id > ?", cutoff, after).
+				Order("id").
+				Limit(purgeBatchSize).
+				Pluck("id", &ids).Error
+			if err != nil {
+				return purged, err
+			}
+
+			for _, id := range ids {
+				if err := r.Purge(table.itemType, id); err != nil {
+					log.Printf("Failed to purge %s %s: %v", table.itemType, id, err)
+					continue
+				}
+				purged++
+			}
+
+			if len(ids) < purgeBatchSize {
+				break
+			}
+			after = ids[len(ids)-1]
+		}
+	}
+	return purged, nil
+}
@@ -14,6 +14,9 @@ type UserRepository interface {
 	Update(user *models.User) error
 	Delete(id uuid.UUID) error
 	Exists(email string) (bool, error)
+	GetStaples(userID uuid.UUID) ([]models.PantryStaple, error)
+	AddStaple(staple *models.PantryStaple) error
+	DeleteStaple(userID, stapleID uuid.UUID) error
 }
 
 type userRepository struct {
@@ -58,4 +61,21 @@ func (r *userRepository) Exists(email string) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
 	return count > 0, err
+}
+
+func (r *userRepository) GetStaples(userID uuid.UUID) ([]models.PantryStaple, error) {
+	var staples []models.PantryStaple
+	err := r.db.Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&staples).Error
+	return staples, err
+}
+
+func (r *userRepository) AddStaple(staple *models.PantryStaple) error {
+	return r.db.Where("user_id = ? AND name = ?", staple.UserID, staple.Name).
+		FirstOrCreate(staple).Error
+}
+
+func (r *userRepository) DeleteStaple(userID, stapleID uuid.UUID) error {
+	return r.db.Where("id = ? AND user_id = ?", stapleID, userID).Delete(&models.PantryStaple{}).Error
 }
\ No newline at end of file
@@ -2,8 +2,12 @@ package services
 
 import (
 	"errors"
+	"sort"
+	"strings"
+	"yummio-backend/internal/exporter"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/repositories"
+	"yummio-backend/internal/units"
 
 	"github.com/google/uuid"
 )
@@ -16,15 +20,21 @@ type CollectionService interface {
 	DeleteCollection(userID, collectionID uuid.UUID) error
 	AddRecipeToCollection(userID, collectionID, recipeID uuid.UUID) error
 	RemoveRecipeFromCollection(userID, collectionID, recipeID uuid.UUID) error
+	PrintCollection(userID, collectionID uuid.UUID, query *models.PrintQuery) ([]byte, error)
 }
 
+// maxBookletRecipes bounds how many recipes a printed collection may hold.
+const maxBookletRecipes = 100
+
 type collectionService struct {
 	collectionRepo repositories.CollectionRepository
+	recipeService  RecipeService
 }
 
-func NewCollectionService(collectionRepo repositories.CollectionRepository) CollectionService {
+func NewCollectionService(collectionRepo repositories.CollectionRepository, recipeService RecipeService) CollectionService {
 	return &collectionService{
 		collectionRepo: collectionRepo,
+		recipeService:  recipeService,
 	}
 }
 
@@ -138,4 +148,56 @@ func (s *collectionService) RemoveRecipeFromCollection(userID, collectionID, rec
 	}
 
 	return s.collectionRepo.RemoveRecipe(collectionID, recipeID)
-}
\ No newline at end of file
+}
+
+// PrintCollection renders a collection as a booklet with a cover page and
+// its recipes in alphabetical order. Recipes without servings are printed
+// unscaled.
+func (s *collectionService) PrintCollection(userID, collectionID uuid.UUID, query *models.PrintQuery) ([]byte, error) {
+	collection, err := s.GetCollection(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(collection.Recipes) > maxBookletRecipes {
+		return nil, errors.New("collection has too many recipes to print")
+	}
+
+	var system units.System
+	if query.System != nil {
+		if system, err = units.ParseSystem(*query.System); err != nil {
+			return nil, err
+		}
+	}
+	format, err := exporter.ParsePrintFormat(query.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &exporter.Booklet{Title: collection.Name}
+	if collection.Description != nil {
+		book.Description = *collection.Description
+	}
+
+	for _, entry := range collection.Recipes {
+		var recipe *models.Recipe
+		if query.Servings != nil && entry.Servings != nil && *entry.Servings > 0 {
+			recipe, err = s.recipeService.GetScaledRecipe(entry.ID, *query.Servings)
+		} else {
+			recipe, err = s.recipeService.GetRecipe(entry.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if system != "" {
+			s.recipeService.ConvertRecipe(recipe, system)
+		}
+		book.Recipes = append(book.Recipes, recipe)
+	}
+	sort.SliceStable(book.Recipes, func(i, j int) bool {
+		return strings.ToLower(book.Recipes[i].Title) < strings.ToLower(book.Recipes[j].Title)
+	})
+
+	return s.recipeService.PrintRecipes(book, format, exporter.PrintOptions{TwoColumn: query.Columns == 2})
+}
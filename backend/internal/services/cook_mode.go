@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/units"
+
+	"github.com/google/uuid"
+)
+
+// stepIngredients turns the links of a step request, which point at
+// positions in the recipe's ingredients, into links to the ingredients
+// themselves. The ingredients must have their IDs set.
+func stepIngredients(links []models.StepIngredientRequest, ingredients []models.Ingredient) ([]models.StepIngredient, error) {
+	var linked []models.StepIngredient
+	for _, link := range links {
+		if link.Ingredient == nil {
+			return nil, errors.New("step ingredient has no position")
+		}
+		position := *link.Ingredient
+		if position < 0 || position >= len(ingredients) {
+			return nil, errors.New("step uses an unknown ingredient")
+		}
+		linked = append(linked, models.StepIngredient{
+			IngredientID: ingredients[position].ID,
+			Amount:       link.Amount,
+			Unit:         link.Unit,
+		})
+	}
+	return linked, nil
+}
+
+// CookMode lays a recipe out step by step. Each step lists the ingredients
+// it uses: the ones the author linked, or else the ones its text mentions.
+// Ingredients are grouped for mise en place by the first step using them.
+func (s *recipeService) CookMode(recipe *models.Recipe) *models.CookMode {
+	ingredients := append([]models.Ingredient(nil), recipe.Ingredients...)
+	sort.SliceStable(ingredients, func(i, j int) bool {
+		return ingredients[i].OrderIndex < ingredients[j].OrderIndex
+	})
+	instructions := append([]models.Instruction(nil), recipe.Instructions...)
+	sort.SliceStable(instructions, func(i, j int) bool {
+		return instructions[i].Step < instructions[j].Step
+	})
+
+	byID := make(map[uuid.UUID]*models.Ingredient, len(ingredients))
+	for i := range ingredients {
+		byID[ingredients[i].ID] = &ingredients[i]
+	}
+	linked := make(map[uuid.UUID]bool)
+	for _, instruction := range instructions {
+		for _, link := range instruction.Ingredients {
+			linked[link.IngredientID] = true
+		}
+	}
+
+	matcher := newStepMatcher(ingredients)
+	firstStep := make(map[uuid.UUID]int)
+	steps := make([]models.CookModeStep, 0, len(instructions))
+	for _, instruction := range instructions {
+		step := models.CookModeStep{
+			Step:         instruction.Step,
+			Instruction:  instruction.Instruction,
+			ImageURL:     instruction.ImageURL,
+			TimerMinutes: instruction.TimerMinutes,
+			Ingredients:  []models.CookModeIngredient{},
+		}
+
+		if len(instruction.Ingredients) > 0 {
+			for _, link := range instruction.Ingredients {
+				ingredient, ok := byID[link.IngredientID]
+				if !ok {
+					continue
+				}
+				item := cookModeIngredient(ingredient)
+				if link.Amount != nil {
+					item.Amount, item.Unit = link.Amount, link.Unit
+				}
+				step.Ingredients = append(step.Ingredients, item)
+			}
+		} else {
+			for _, ingredient := range matcher.match(instruction.Instruction) {
+				item := cookModeIngredient(ingredient)
+				item.Matched = true
+				// The amount was already given where it was first used
+				if _, used := firstStep[ingredient.ID]; used || linked[ingredient.ID] {
+					item.Amount, item.Unit = nil, nil
+				}
+				step.Ingredients = append(step.Ingredients, item)
+			}
+		}
+
+		for _, item := range step.Ingredients {
+			if _, used := firstStep[item.IngredientID]; !used {
+				firstStep[item.IngredientID] = instruction.Step
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	return &models.CookMode{
+		RecipeID:    recipe.ID,
+		Title:       recipe.Title,
+		Servings:    recipe.Servings,
+		MiseEnPlace: miseEnPlace(ingredients, firstStep, steps),
+		Steps:       steps,
+	}
+}
+
+// miseEnPlace groups ingredients by the first step using them, in step
+// order. Ingredients no step uses come first, to be got ready up front.
+func miseEnPlace(ingredients []models.Ingredient, firstStep map[uuid.UUID]int, steps []models.CookModeStep) []models.MiseEnPlaceGroup {
+	groups := []models.MiseEnPlaceGroup{}
+
+	var unused []models.CookModeIngredient
+	byStep := make(map[int][]models.CookModeIngredient)
+	for i := range ingredients {
+		item := cookModeIngredient(&ingredients[i])
+		if step, ok := firstStep[item.IngredientID]; ok {
+			byStep[step] = append(byStep[step], item)
+		} else {
+			unused = append(unused, item)
+		}
+	}
+
+	if len(unused) > 0 {
+		groups = append(groups, models.MiseEnPlaceGroup{Ingredients: unused})
+	}
+	for _, step := range steps {
+		if items := byStep[step.Step]; len(items) > 0 {
+			number := step.Step
+			groups = append(groups, models.MiseEnPlaceGroup{Step: &number, Ingredients: items})
+			delete(byStep, step.Step)
+		}
+	}
+	return groups
+}
+
+func cookModeIngredient(ingredient *models.Ingredient) models.CookModeIngredient {
+	return models.CookModeIngredient{
+		IngredientID: ingredient.ID,
+		Name:         ingredient.Name,
+		Amount:       ingredient.Amount,
+		Unit:         ingredient.Unit,
+		Notes:        ingredient.Notes,
+		SubRecipeID:  ingredient.SubRecipeID,
+	}
+}
+
+// stepMatcher finds the ingredients a step's text mentions, for steps the
+// author didn't link. An ingredient is mentioned by its whole name, or by
+// its last word when no other ingredient ends in that word, so "olive oil"
+// is found in "heat the oil" but "salt" alone can't tell "sea salt" from
+// "kosher salt".
+type stepMatcher struct {
+	ingredients []*models.Ingredient
+	names       [][]string
+	heads       map[string]int // how many ingredients end in each word
+}
+
+func newStepMatcher(ingredients []models.Ingredient) *stepMatcher {
+	m := &stepMatcher{heads: make(map[string]int)}
+	for i := range ingredients {
+		words := matchWords(ingredients[i].Name)
+		if len(words) == 0 {
+			continue
+		}
+		m.ingredients = append(m.ingredients, &ingredients[i])
+		m.names = append(m.names, words)
+		m.heads[words[len(words)-1]]++
+	}
+	return m
+}
+
+// match returns the ingredients mentioned in text, in the order they are
+// mentioned.
+func (m *stepMatcher) match(text string) []*models.Ingredient {
+	words := matchWords(text)
+
+	type mention struct {
+		ingredient *models.Ingredient
+		at         int
+	}
+	var mentions []mention
+	for i, name := range m.names {
+		at := indexWords(words, name)
+		if at < 0 && len(name) > 1 && m.heads[name[len(name)-1]] == 1 {
+			at = indexWords(words, name[len(name)-1:])
+		}
+		if at >= 0 {
+			mentions = append(mentions, mention{m.ingredients[i], at})
+		}
+	}
+
+	sort.SliceStable(mentions, func(i, j int) bool {
+		return mentions[i].at < mentions[j].at
+	})
+	found := make([]*models.Ingredient, len(mentions))
+	for i, mention := range mentions {
+		found[i] = mention.ingredient
+	}
+	return found
+}
+
+// matchWords splits text into lowercase words without punctuation, each
+// normalized like ingredientKey so plurals match.
+func matchWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = ingredientKey(word)
+	}
+	return words
+}
+
+// indexWords returns where the run of words name starts in words, or -1.
+func indexWords(words, name []string) int {
+	for i := 0; i+len(name) <= len(words); i++ {
+		found := true
+		for j := range name {
+			if words[i+j] != name[j] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
+
+// scaleStepIngredients scales the part of each ingredient the steps use.
+func scaleStepIngredients(instructions []models.Instruction, factor float64) {
+	for i := range instructions {
+		for j := range instructions[i].Ingredients {
+			link := &instructions[i].Ingredients[j]
+			if link.Amount == nil {
+				continue
+			}
+
+			unit := ""
+			if link.Unit != nil {
+				unit = *link.Unit
+			}
+			amount, scaledUnit := units.Scale(*link.Amount, unit, factor)
+			link.Amount = &amount
+			if link.Unit != nil {
+				link.Unit = &scaledUnit
+			}
+		}
+	}
+}
+
+// convertStepIngredients converts the part of each ingredient the steps use
+// to a measurement system.
+func convertStepIngredients(recipe *models.Recipe, system units.System) {
+	names := make(map[uuid.UUID]string, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		names[ingredient.ID] = ingredient.Name
+	}
+
+	for i := range recipe.Instructions {
+		for j := range recipe.Instructions[i].Ingredients {
+			link := &recipe.Instructions[i].Ingredients[j]
+			if link.Amount == nil || link.Unit == nil {
+				continue
+			}
+
+			amount, unit, ok := units.ConvertIngredient(*link.Amount, *link.Unit, names[link.IngredientID], system)
+			if ok {
+				link.Amount = &amount
+				link.Unit = &unit
+			}
+		}
+	}
+}
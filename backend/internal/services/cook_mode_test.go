@@ -0,0 +1,90 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestStepMatcher(t *testing.T) {
+	ingredients := []models.Ingredient{
+		{Name: "Olive oil"},
+		{Name: "sea salt"},
+		{Name: "kosher salt"},
+		{Name: "onions"},
+		{Name: "garlic"},
+		{Name: "cherry tomatoes"},
+		{Name: "  "},
+	}
+	matcher := newStepMatcher(ingredients)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Heat the olive oil in a pan.", []string{"Olive oil"}},
+		{"Heat the oil, then add the onion.", []string{"Olive oil", "onions"}},
+		{"Add the garlic and the olive oil.", []string{"garlic", "Olive oil"}},
+		{"Season with sea salt.", []string{"sea salt"}},
+		{"Season with salt.", nil},
+		{"Halve the tomatoes.", []string{"cherry tomatoes"}},
+		{"Add the CHERRY TOMATOES!", []string{"cherry tomatoes"}},
+		{"Boil some garlicky water.", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var got []string
+			for _, ingredient := range matcher.match(tt.text) {
+				got = append(got, ingredient.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("match(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepIngredients(t *testing.T) {
+	ingredients := []models.Ingredient{{ID: uuid.New(), Name: "flour"}, {ID: uuid.New(), Name: "eggs"}}
+	position := func(i int) *int { return &i }
+
+	tests := []struct {
+		name    string
+		links   []models.StepIngredientRequest
+		want    []uuid.UUID
+		wantErr string
+	}{
+		{"first ingredient", []models.StepIngredientRequest{{Ingredient: position(0)}}, []uuid.UUID{ingredients[0].ID}, ""},
+		{"both ingredients", []models.StepIngredientRequest{{Ingredient: position(1)}, {Ingredient: position(0)}}, []uuid.UUID{ingredients[1].ID, ingredients[0].ID}, ""},
+		{"no position", []models.StepIngredientRequest{{}}, nil, "step ingredient has no position"},
+		{"negative position", []models.StepIngredientRequest{{Ingredient: position(-1)}}, nil, "step uses an unknown ingredient"},
+		{"position past the end", []models.StepIngredientRequest{{Ingredient: position(2)}}, nil, "step uses an unknown ingredient"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linked, err := stepIngredients(tt.links, ingredients)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []uuid.UUID
+			for _, link := range linked {
+				got = append(got, link.IngredientID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("linked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"yummio-backend/internal/models"
+)
+
+// mealSlotTimes are the local start times used for each meal slot in
+// calendar feeds, as hour and minute.
+var mealSlotTimes = map[string][2]int{
+	"breakfast": {8, 0},
+	"lunch":     {12, 30},
+	"snack":     {16, 0},
+	"drink":     {17, 0},
+	"dinner":    {18, 30},
+	"dessert":   {20, 0},
+}
+
+// defaultMealMinutes is the event length used when a recipe has no times.
+const defaultMealMinutes = 30
+
+// renderMealPlanCalendar renders meal plan entries as an iCalendar document
+// with one VEVENT per planned meal. Start times are floating so calendar
+// apps show them in the user's local time zone.
+func renderMealPlanCalendar(entries []models.MealPlanEntry, appURL string) string {
+	var b strings.Builder
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Yummio//Meal Plan//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "X-WR-CALNAME:Yummio Meal Plan")
+
+	for _, entry := range entries {
+		recipe := entry.Recipe
+
+		slot, ok := mealSlotTimes[entry.MealType]
+		if !ok {
+			slot = mealSlotTimes["dinner"]
+		}
+		start := time.Date(entry.Date.Year(), entry.Date.Month(), entry.Date.Day(), slot[0], slot[1], 0, 0, time.UTC)
+
+		minutes := 0
+		if recipe.PrepTime != nil {
+			minutes += *recipe.PrepTime
+		}
+		if recipe.CookTime != nil {
+			minutes += *recipe.CookTime
+		}
+		if minutes == 0 {
+			minutes = defaultMealMinutes
+		}
+
+		link := strings.TrimRight(appURL, "/") + "/recipe/" + recipe.ID.String()
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+entry.ID.String()+"@yummio")
+		writeICalLine(&b, "DTSTAMP:"+entry.UpdatedAt.UTC().Format("20060102T150405Z"))
+		writeICalLine(&b, "DTSTART:"+start.Format("20060102T150405"))
+		writeICalLine(&b, fmt.Sprintf("DURATION:PT%dM", minutes))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(mealSlotName(entry.MealType)+": "+recipe.Title))
+		writeICalLine(&b, "DESCRIPTION:"+escapeICalText(mealDescription(entry, link)))
+		writeICalLine(&b, "URL:"+link)
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func mealSlotName(mealType string) string {
+	if mealType == "" {
+		return "Meal"
+	}
+	return strings.ToUpper(mealType[:1]) + mealType[1:]
+}
+
+// mealDescription summarizes the ingredients of a planned meal, scaled to
+// the planned servings.
+func mealDescription(entry models.MealPlanEntry, link string) string {
+	servings := entry.Servings
+	if servings == nil {
+		servings = entry.Recipe.Servings
+	}
+
+	var lines []string
+	if servings != nil {
+		lines = append(lines, fmt.Sprintf("Servings: %d", *servings))
+	}
+	if entry.Note != nil && *entry.Note != "" {
+		lines = append(lines, *entry.Note)
+	}
+
+	items := recipeItems(&entry.Recipe, servings)
+	if len(items) > 0 {
+		lines = append(lines, "", "Ingredients:")
+	}
+	for _, item := range items {
+		line := "- "
+		if item.Amount != nil {
+			line += strconv.FormatFloat(*item.Amount, 'f', -1, 64) + " "
+		}
+		if item.Unit != nil && *item.Unit != "" {
+			line += *item.Unit + " "
+		}
+		lines = append(lines, line+item.Name)
+	}
+
+	lines = append(lines, "", link)
+	return strings.Join(lines, "\n")
+}
+
+// escapeICalText escapes a TEXT property value as required by RFC 5545.
+func escapeICalText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeICalLine writes a content line, folding it at 75 octets without
+// splitting multi-byte characters.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
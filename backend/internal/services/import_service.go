@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+	"yummio-backend/internal/importer"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type ImportService interface {
+	StartArchiveImport(userID uuid.UUID, filename string, data []byte) (*models.ImportJob, error)
+	GetJob(userID, jobID uuid.UUID) (*models.ImportJob, error)
+	GetJobs(userID uuid.UUID) ([]models.ImportJob, error)
+	FailInterruptedJobs() error
+}
+
+// maxListedImportJobs bounds how many past imports are listed.
+const maxListedImportJobs = 20
+
+type importService struct {
+	importJobRepo  repositories.ImportJobRepository
+	recipeRepo     repositories.RecipeRepository
+	recipeService  RecipeService
+	uploadService  UploadService
+	maxArchiveSize int64
+}
+
+func NewImportService(importJobRepo repositories.ImportJobRepository, recipeRepo repositories.RecipeRepository, recipeService RecipeService, uploadService UploadService, maxArchiveSize int64) ImportService {
+	return &importService{
+		importJobRepo:  importJobRepo,
+		recipeRepo:     recipeRepo,
+		recipeService:  recipeService,
+		uploadService:  uploadService,
+		maxArchiveSize: maxArchiveSize,
+	}
+}
+
+// StartArchiveImport reads an archive exported from another recipe manager
+// and imports its recipes in the background. The returned job reports the
+// progress and the outcome of every recipe.
+func (s *importService) StartArchiveImport(userID uuid.UUID, filename string, data []byte) (*models.ImportJob, error) {
+	format, recipes, err := importer.ReadArchive(data, s.maxArchiveSize)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ImportJob{
+		UserID:   userID,
+		Format:   string(format),
+		Filename: filename,
+		Status:   "pending",
+		Total:    len(recipes),
+		Results:  []models.ImportJobResult{},
+	}
+	if err := s.importJobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	go s.runImport(*job, recipes)
+	return job, nil
+}
+
+func (s *importService) runImport(job models.ImportJob, recipes []importer.ArchiveRecipe) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Import job %s panicked: %v", job.ID, r)
+			s.finishImport(&job, "import stopped unexpectedly")
+		}
+	}()
+
+	job.Status = "running"
+	if err := s.importJobRepo.Update(&job); err != nil {
+		log.Printf("Failed to start import job %s: %v", job.ID, err)
+	}
+
+	for _, recipe := range recipes {
+		result := s.importRecipe(job.UserID, recipe)
+		switch result.Status {
+		case "imported":
+			job.Imported++
+		case "skipped":
+			job.Skipped++
+		default:
+			job.Failed++
+		}
+		job.Results = append(job.Results, result)
+
+		if err := s.importJobRepo.Update(&job); err != nil {
+			log.Printf("Failed to update import job %s: %v", job.ID, err)
+		}
+	}
+
+	s.finishImport(&job, "")
+}
+
+func (s *importService) finishImport(job *models.ImportJob, failure string) {
+	now := time.Now()
+	job.Status = "completed"
+	job.CompletedAt = &now
+	if failure != "" {
+		job.Status = "failed"
+		job.Error = &failure
+	}
+	if err := s.importJobRepo.Update(job); err != nil {
+		log.Printf("Failed to finish import job %s: %v", job.ID, err)
+	}
+}
+
+// importRecipe saves one recipe of an archive unless the user already has
+// it. Recipes are imported as private, since they come from the user's own
+// collection elsewhere.
+func (s *importService) importRecipe(userID uuid.UUID, recipe importer.ArchiveRecipe) models.ImportJobResult {
+	result := models.ImportJobResult{Source: recipe.Source}
+	if recipe.Err != nil {
+		result.Status = "failed"
+		result.Error = recipe.Err.Error()
+		return result
+	}
+
+	req := recipe.Recipe
+	result.Title = req.Title
+
+	duplicate, err := s.recipeRepo.FindDuplicate(userID, req.Title, req.SourceURL)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	if duplicate != nil {
+		result.Status = "skipped"
+		result.RecipeID = &duplicate.ID
+		result.Error = "you already have this recipe"
+		return result
+	}
+
+	if recipe.Image != nil {
+		url, err := s.uploadImage(recipe.Image)
+		if err != nil {
+			result.Warning = "image could not be uploaded: " + err.Error()
+		} else {
+			req.ImageURL = &url
+		}
+	}
+	if req.IsPublic == nil {
+		isPublic := false
+		req.IsPublic = &isPublic
+	}
+
+	created, err := s.recipeService.CreateRecipe(userID, req)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = "imported"
+	result.RecipeID = &created.ID
+	return result
+}
+
+// uploadImage reads an image out of the archive and uploads it.
+func (s *importService) uploadImage(image *importer.ArchiveImage) (string, error) {
+	data, err := image.Read()
+	if err != nil {
+		return "", err
+	}
+	return s.uploadService.UploadImageData(data, image.Name)
+}
+
+func (s *importService) GetJob(userID, jobID uuid.UUID) (*models.ImportJob, error) {
+	job, err := s.importJobRepo.GetByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check ownership
+	if job.UserID != userID {
+		return nil, errors.New("unauthorized to access this import job")
+	}
+
+	return job, nil
+}
+
+func (s *importService) GetJobs(userID uuid.UUID) ([]models.ImportJob, error) {
+	return s.importJobRepo.GetByUserID(userID, maxListedImportJobs)
+}
+
+// FailInterruptedJobs marks imports that were cut off by a restart as
+// failed, so users don't wait on them forever.
+func (s *importService) FailInterruptedJobs() error {
+	_, err := s.importJobRepo.FailUnfinished("import was interrupted, please upload the archive again")
+	return err
+}
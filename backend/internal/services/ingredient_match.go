@@ -0,0 +1,52 @@
+package services
+
+import (
+	"strings"
+	"yummio-backend/internal/models"
+)
+
+// hasIngredient reports whether an ingredient the user has covers a recipe
+// ingredient. Whole words must match, so "chicken" covers "chicken breast"
+// but "salt" does not cover "salted butter".
+func hasIngredient(recipeIngredient, available string) bool {
+	name := " " + ingredientWords(recipeIngredient) + " "
+	return strings.Contains(name, " "+ingredientWords(available)+" ")
+}
+
+// ingredientWords normalizes every word of an ingredient name the way
+// ingredientKey normalizes the last one.
+func ingredientWords(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	for i, word := range words {
+		words[i] = ingredientKey(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// matchRecipe compares a recipe's ingredients against the ones available.
+// Staples count as neither matched nor missing. used reports which of the
+// available ingredients the recipe needs.
+func matchRecipe(recipe *models.Recipe, available []string, staples []models.PantryStaple) (matched, missing []string, used []bool) {
+	matched, missing = []string{}, []string{}
+	used = make([]bool, len(available))
+
+	for _, ingredient := range recipe.Ingredients {
+		found := false
+		for i, name := range available {
+			if hasIngredient(ingredient.Name, name) {
+				used[i] = true
+				found = true
+			}
+		}
+
+		switch {
+		case found:
+			matched = append(matched, ingredient.Name)
+		case isStaple(ingredient.Name, staples):
+		default:
+			missing = append(missing, ingredient.Name)
+		}
+	}
+
+	return matched, missing, used
+}
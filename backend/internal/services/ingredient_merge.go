@@ -0,0 +1,166 @@
+package services
+
+import (
+	"strings"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/units"
+)
+
+// recipeItems scales a recipe's ingredients to the requested servings and
+// returns them as shopping list items that remember the recipe they came from.
+func recipeItems(recipe *models.Recipe, servings *int) []models.ShoppingListItem {
+	factor := 1.0
+	if servings != nil && recipe.Servings != nil && *recipe.Servings > 0 {
+		factor = float64(*servings) / float64(*recipe.Servings)
+	}
+
+	var items []models.ShoppingListItem
+	for _, ingredient := range recipe.Ingredients {
+		item := models.ShoppingListItem{
+			Name: strings.TrimSpace(ingredient.Name),
+			Unit: ingredient.Unit,
+		}
+
+		if ingredient.Amount != nil {
+			unit := ""
+			if ingredient.Unit != nil {
+				unit = *ingredient.Unit
+			}
+			amount, scaledUnit := units.Scale(*ingredient.Amount, unit, factor)
+			item.Amount = &amount
+			if ingredient.Unit != nil {
+				item.Unit = &scaledUnit
+			}
+		}
+
+		item.Sources = []models.ShoppingListItemSource{{
+			RecipeID:    recipe.ID,
+			RecipeTitle: recipe.Title,
+			Amount:      item.Amount,
+			Unit:        item.Unit,
+		}}
+		items = append(items, item)
+	}
+	return items
+}
+
+// mergeItem adds an item to the first uncompleted item of the same
+// ingredient whose amount it can be combined with, or appends it. Items the
+// user added by hand have no sources and are left alone, so removing a recipe
+// never touches them. It returns the updated items and the index of the item
+// that was touched.
+func mergeItem(items []models.ShoppingListItem, item models.ShoppingListItem) ([]models.ShoppingListItem, int) {
+	key := ingredientKey(item.Name)
+	for i := range items {
+		existing := &items[i]
+		if existing.Completed || len(existing.Sources) == 0 || ingredientKey(existing.Name) != key {
+			continue
+		}
+
+		amount, unit, ok := combineAmounts(existing.Amount, existing.Unit, item.Amount, item.Unit, item.Name)
+		if !ok {
+			continue
+		}
+
+		existing.Amount = amount
+		existing.Unit = unit
+		existing.Sources = append(existing.Sources, item.Sources...)
+		return items, i
+	}
+
+	item.OrderIndex = len(items)
+	items = append(items, item)
+	return items, len(items) - 1
+}
+
+// sourcesAmount adds up what recipes contributed to an item. It returns
+// false if the amounts can't be combined.
+func sourcesAmount(name string, sources []models.ShoppingListItemSource) (*float64, *string, bool) {
+	amount, unit := sources[0].Amount, sources[0].Unit
+	for _, source := range sources[1:] {
+		var ok bool
+		amount, unit, ok = combineAmounts(amount, unit, source.Amount, source.Unit, name)
+		if !ok {
+			return nil, nil, false
+		}
+	}
+	return amount, unit, true
+}
+
+// combineAmounts adds an amount to an item's amount. Items without an
+// amount only combine with other amount-less entries of the same unit.
+func combineAmounts(amount *float64, unit *string, other *float64, otherUnit *string, name string) (*float64, *string, bool) {
+	u, o := "", ""
+	if unit != nil {
+		u = *unit
+	}
+	if otherUnit != nil {
+		o = *otherUnit
+	}
+
+	if amount == nil || other == nil {
+		if amount != nil || other != nil || units.Normalize(u) != units.Normalize(o) {
+			return nil, nil, false
+		}
+		return nil, unit, true
+	}
+
+	total, totalUnit, ok := units.Combine(*amount, u, *other, o, name)
+	if !ok {
+		return nil, nil, false
+	}
+	if unit == nil {
+		return &total, nil, true
+	}
+	return &total, &totalUnit, true
+}
+
+// ingredientKey normalizes an ingredient name so "Eggs" and "egg" match.
+func ingredientKey(name string) string {
+	key := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	switch {
+	case strings.HasSuffix(key, "ies"):
+		key = strings.TrimSuffix(key, "ies") + "y"
+	case strings.HasSuffix(key, "oes"):
+		key = strings.TrimSuffix(key, "es")
+	case strings.HasSuffix(key, "s") && !strings.HasSuffix(key, "ss"):
+		key = strings.TrimSuffix(key, "s")
+	}
+	return key
+}
+
+// stapleModifiers are words that don't change what an ingredient is, so
+// "kosher salt" is still the "salt" staple while "smoked sea salt" and
+// "coconut water" are not staples at all.
+var stapleModifiers = map[string]bool{
+	"kosher": true, "sea": true, "table": true, "fine": true, "coarse": true,
+	"flaky": true, "iodized": true, "ground": true, "cracked": true,
+	"freshly": true, "fresh": true, "cold": true, "warm": true, "hot": true,
+	"lukewarm": true, "boiling": true, "tap": true, "filtered": true,
+	"cooking": true, "neutral": true, "vegetable": true,
+}
+
+// isStaple reports whether an ingredient is one of the user's pantry
+// staples, either by name or with only stapleModifiers in front of it.
+func isStaple(name string, staples []models.PantryStaple) bool {
+	key := ingredientKey(name)
+	for _, staple := range staples {
+		stapleKey := ingredientKey(staple.Name)
+		if key == stapleKey {
+			return true
+		}
+
+		prefix, ok := strings.CutSuffix(key, " "+stapleKey)
+		if !ok {
+			continue
+		}
+		modifiers := true
+		for _, word := range strings.Fields(prefix) {
+			modifiers = modifiers && stapleModifiers[word]
+		}
+		if modifiers {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"yummio-backend/internal/models"
+)
+
+func TestSourcesAmount(t *testing.T) {
+	source := func(amount float64, unit string) models.ShoppingListItemSource {
+		return models.ShoppingListItemSource{Amount: &amount, Unit: &unit}
+	}
+
+	tests := []struct {
+		name     string
+		sources  []models.ShoppingListItemSource
+		want     float64
+		wantUnit string
+		ok       bool
+	}{
+		{"one source", []models.ShoppingListItemSource{source(2, "cup")}, 2, "cup", true},
+		{"same unit", []models.ShoppingListItemSource{source(1, "cup"), source(0.5, "cup")}, 1.5, "cup", true},
+		{"metric and imperial", []models.ShoppingListItemSource{source(2, "cup"), source(250, "ml")}, 3, "cup", true},
+		{"promoted unit", []models.ShoppingListItemSource{source(8, "tbsp"), source(8, "tbsp")}, 1, "cup", true},
+		{"volume and weight without density", []models.ShoppingListItemSource{source(1, "cup"), source(100, "g")}, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, unit, ok := sourcesAmount("milk", tt.sources)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if amount == nil || unit == nil || *amount != tt.want || *unit != tt.wantUnit {
+				t.Errorf("sourcesAmount() = %v %v, want %v %q", amount, unit, tt.want, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestIsStaple(t *testing.T) {
+	staples := []models.PantryStaple{{Name: "salt"}, {Name: "water"}, {Name: "oil"}, {Name: "black pepper"}}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Salt", true},
+		{"kosher salt", true},
+		{"coarse sea salt", true},
+		{"warm water", true},
+		{"vegetable oil", true},
+		{"freshly ground black pepper", true},
+		{"smoked sea salt", false},
+		{"coconut water", false},
+		{"truffle oil", false},
+		{"salted butter", false},
+		{"pepper", false},
+	}
+
+	for _, tt := range tests {
+		if got := isStaple(tt.name, staples); got != tt.want {
+			t.Errorf("isStaple(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
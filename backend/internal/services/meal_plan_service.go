@@ -0,0 +1,466 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// maxMealPlanDays bounds the date ranges a meal plan can be fetched for.
+const maxMealPlanDays = 62
+
+// Calendar feeds cover recent and upcoming meals.
+const (
+	feedDaysBack  = 14
+	feedDaysAhead = 56
+)
+
+type MealPlanService interface {
+	GetMealPlan(userID uuid.UUID, from, to string) (*models.MealPlan, error)
+	CreateEntry(userID uuid.UUID, req *models.MealPlanEntryCreateRequest) (*models.MealPlanEntry, error)
+	UpdateEntry(userID, entryID uuid.UUID, req *models.MealPlanEntryUpdateRequest) (*models.MealPlanEntry, error)
+	DeleteEntry(userID, entryID uuid.UUID) error
+	MoveEntry(userID, entryID uuid.UUID, req *models.MealPlanEntryMoveRequest) (*models.MealPlanEntry, error)
+	CopyEntry(userID, entryID uuid.UUID, req *models.MealPlanEntryMoveRequest) (*models.MealPlanEntry, error)
+	RepeatWeek(userID uuid.UUID, req *models.MealPlanRepeatWeekRequest) (*models.MealPlan, error)
+	GenerateShoppingList(userID uuid.UUID, from, to string, req *models.MealPlanShoppingListRequest) (*models.ShoppingList, error)
+	CreateFeedToken(userID uuid.UUID) (*models.MealPlanFeedResponse, error)
+	RevokeFeedToken(userID uuid.UUID) error
+	GetFeed(token string) (string, error)
+}
+
+type mealPlanService struct {
+	mealPlanRepo     repositories.MealPlanRepository
+	recipeRepo       repositories.RecipeRepository
+	shoppingListRepo repositories.ShoppingListRepository
+	userRepo         repositories.UserRepository
+	appURL           string
+	apiURL           string
+}
+
+func NewMealPlanService(mealPlanRepo repositories.MealPlanRepository, recipeRepo repositories.RecipeRepository, shoppingListRepo repositories.ShoppingListRepository, userRepo repositories.UserRepository, appURL, apiURL string) MealPlanService {
+	return &mealPlanService{
+		mealPlanRepo:     mealPlanRepo,
+		recipeRepo:       recipeRepo,
+		shoppingListRepo: shoppingListRepo,
+		userRepo:         userRepo,
+		appURL:           appURL,
+		apiURL:           apiURL,
+	}
+}
+
+func (s *mealPlanService) GetMealPlan(userID uuid.UUID, from, to string) (*models.MealPlan, error) {
+	fromDate, toDate, err := parseDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getMealPlan(userID, fromDate, toDate)
+}
+
+func (s *mealPlanService) CreateEntry(userID uuid.UUID, req *models.MealPlanEntryCreateRequest) (*models.MealPlanEntry, error) {
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+
+	if err := s.checkRecipeAccess(userID, req.RecipeID); err != nil {
+		return nil, err
+	}
+
+	entry := &models.MealPlanEntry{
+		UserID:   userID,
+		RecipeID: req.RecipeID,
+		Date:     date,
+		MealType: req.MealType,
+		Servings: req.Servings,
+		Note:     req.Note,
+	}
+
+	if err := s.mealPlanRepo.Create(entry); err != nil {
+		return nil, err
+	}
+
+	return s.mealPlanRepo.GetByID(entry.ID)
+}
+
+func (s *mealPlanService) UpdateEntry(userID, entryID uuid.UUID, req *models.MealPlanEntryUpdateRequest) (*models.MealPlanEntry, error) {
+	entry, err := s.getOwnedEntry(userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields
+	if req.RecipeID != nil {
+		if err := s.checkRecipeAccess(userID, *req.RecipeID); err != nil {
+			return nil, err
+		}
+		entry.RecipeID = *req.RecipeID
+	}
+	if req.Date != nil {
+		date, err := time.Parse(dateLayout, *req.Date)
+		if err != nil {
+			return nil, errors.New("invalid date")
+		}
+		entry.Date = date
+	}
+	if req.MealType != nil {
+		entry.MealType = *req.MealType
+	}
+	if req.Servings != nil {
+		entry.Servings = req.Servings
+	}
+	if req.Note != nil {
+		entry.Note = req.Note
+	}
+
+	if err := s.mealPlanRepo.Update(entry); err != nil {
+		return nil, err
+	}
+
+	return s.mealPlanRepo.GetByID(entry.ID)
+}
+
+func (s *mealPlanService) DeleteEntry(userID, entryID uuid.UUID) error {
+	if _, err := s.getOwnedEntry(userID, entryID); err != nil {
+		return err
+	}
+
+	return s.mealPlanRepo.Delete(entryID)
+}
+
+func (s *mealPlanService) MoveEntry(userID, entryID uuid.UUID, req *models.MealPlanEntryMoveRequest) (*models.MealPlanEntry, error) {
+	entry, err := s.getOwnedEntry(userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+
+	entry.Date = date
+	if req.MealType != nil {
+		entry.MealType = *req.MealType
+	}
+
+	if err := s.mealPlanRepo.Update(entry); err != nil {
+		return nil, err
+	}
+
+	return s.mealPlanRepo.GetByID(entry.ID)
+}
+
+func (s *mealPlanService) CopyEntry(userID, entryID uuid.UUID, req *models.MealPlanEntryMoveRequest) (*models.MealPlanEntry, error) {
+	entry, err := s.getOwnedEntry(userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+
+	copied := &models.MealPlanEntry{
+		UserID:   userID,
+		RecipeID: entry.RecipeID,
+		Date:     date,
+		MealType: entry.MealType,
+		Servings: entry.Servings,
+		Note:     entry.Note,
+	}
+	if req.MealType != nil {
+		copied.MealType = *req.MealType
+	}
+
+	if err := s.mealPlanRepo.Create(copied); err != nil {
+		return nil, err
+	}
+
+	return s.mealPlanRepo.GetByID(copied.ID)
+}
+
+func (s *mealPlanService) RepeatWeek(userID uuid.UUID, req *models.MealPlanRepeatWeekRequest) (*models.MealPlan, error) {
+	fromWeek, err := time.Parse(dateLayout, req.FromWeek)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+
+	toWeek, err := time.Parse(dateLayout, req.ToWeek)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+
+	days := daysBetween(fromWeek, toWeek)
+	if days == 0 {
+		return nil, errors.New("cannot repeat a week onto itself")
+	}
+
+	entries, err := s.mealPlanRepo.GetByDateRange(userID, fromWeek, fromWeek.AddDate(0, 0, 6))
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.mealPlanRepo.GetByDateRange(userID, toWeek, toWeek.AddDate(0, 0, 6))
+	if err != nil {
+		return nil, err
+	}
+
+	// Meals already planned in the target week are skipped, so repeating a
+	// week twice doesn't plan everything twice
+	planned := make(map[string]bool)
+	for _, entry := range existing {
+		planned[mealKey(entry.Date, entry.MealType, entry.RecipeID)] = true
+	}
+
+	// Shift every entry by the distance between the two weeks
+	var copies []models.MealPlanEntry
+	for _, entry := range entries {
+		date := entry.Date.AddDate(0, 0, days)
+		key := mealKey(date, entry.MealType, entry.RecipeID)
+		if planned[key] {
+			continue
+		}
+		planned[key] = true
+
+		copies = append(copies, models.MealPlanEntry{
+			UserID:   userID,
+			RecipeID: entry.RecipeID,
+			Date:     date,
+			MealType: entry.MealType,
+			Servings: entry.Servings,
+			Note:     entry.Note,
+		})
+	}
+
+	if len(copies) > 0 {
+		if err := s.mealPlanRepo.CreateBatch(copies); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.getMealPlan(userID, toWeek, toWeek.AddDate(0, 0, 6))
+}
+
+func (s *mealPlanService) GenerateShoppingList(userID uuid.UUID, from, to string, req *models.MealPlanShoppingListRequest) (*models.ShoppingList, error) {
+	fromDate, toDate, err := parseDateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.mealPlanRepo.GetByDateRange(userID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var staples []models.PantryStaple
+	if !req.IncludeStaples {
+		staples, err = s.userRepo.GetStaples(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	list := &models.ShoppingList{
+		UserID: userID,
+		Name:   fmt.Sprintf("Meal plan %s - %s", fromDate.Format(dateLayout), toDate.Format(dateLayout)),
+	}
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+
+	recipes := make(map[uuid.UUID]*models.Recipe)
+	for _, entry := range entries {
+		recipe, ok := recipes[entry.RecipeID]
+		if !ok {
+			recipe, err = s.recipeRepo.GetByID(entry.RecipeID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if recipe != nil {
+				recipe = withSubRecipes(s.recipeRepo, recipe)
+			}
+			recipes[entry.RecipeID] = recipe
+		}
+
+		// Skip recipes that were deleted, made private or unpublished since
+		// they were planned
+		if recipe == nil || (recipe.UserID != userID && !recipe.IsListed()) {
+			continue
+		}
+
+		servings := entry.Servings
+		if servings == nil {
+			servings = recipe.Servings
+		}
+
+		for _, item := range recipeItems(recipe, servings) {
+			if isStaple(item.Name, staples) {
+				continue
+			}
+			list.Items, _ = mergeItem(list.Items, item)
+		}
+	}
+
+	if err := s.shoppingListRepo.Create(list); err != nil {
+		return nil, err
+	}
+
+	return s.shoppingListRepo.GetByID(list.ID)
+}
+
+// CreateFeedToken issues a calendar feed token. The feed URL is built from
+// the configured app URL rather than the request, whose headers can't be
+// trusted.
+func (s *mealPlanService) CreateFeedToken(userID uuid.UUID) (*models.MealPlanFeedResponse, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	feedToken := &models.MealPlanFeedToken{
+		UserID:    userID,
+		TokenHash: hashFeedToken(token),
+	}
+
+	if err := s.mealPlanRepo.SaveFeedToken(feedToken); err != nil {
+		return nil, err
+	}
+
+	return &models.MealPlanFeedResponse{
+		Token:   token,
+		FeedURL: strings.TrimRight(s.apiURL, "/") + "/api/v1/meal-plans/feed.ics?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+func (s *mealPlanService) RevokeFeedToken(userID uuid.UUID) error {
+	return s.mealPlanRepo.DeleteFeedToken(userID)
+}
+
+func (s *mealPlanService) GetFeed(token string) (string, error) {
+	feedToken, err := s.mealPlanRepo.GetFeedToken(hashFeedToken(token))
+	if err != nil {
+		return "", errors.New("invalid feed token")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	entries, err := s.mealPlanRepo.GetByDateRange(feedToken.UserID, today.AddDate(0, 0, -feedDaysBack), today.AddDate(0, 0, feedDaysAhead))
+	if err != nil {
+		return "", err
+	}
+
+	// Leave out recipes that were made private or unpublished since they were planned
+	var visible []models.MealPlanEntry
+	for _, entry := range entries {
+		if entry.Recipe.UserID == feedToken.UserID || entry.Recipe.IsListed() {
+			visible = append(visible, entry)
+		}
+	}
+
+	return renderMealPlanCalendar(visible, s.appURL), nil
+}
+
+func (s *mealPlanService) getMealPlan(userID uuid.UUID, from, to time.Time) (*models.MealPlan, error) {
+	entries, err := s.mealPlanRepo.GetByDateRange(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MealPlan{
+		From:    from,
+		To:      to,
+		Entries: entries,
+	}, nil
+}
+
+func (s *mealPlanService) getOwnedEntry(userID, entryID uuid.UUID) (*models.MealPlanEntry, error) {
+	entry, err := s.mealPlanRepo.GetByID(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check ownership
+	if entry.UserID != userID {
+		return nil, errors.New("unauthorized to modify this meal plan entry")
+	}
+
+	return entry, nil
+}
+
+func (s *mealPlanService) checkRecipeAccess(userID, recipeID uuid.UUID) error {
+	recipe, err := s.recipeRepo.GetByID(recipeID)
+	if err != nil {
+		return err
+	}
+
+	if recipe.UserID != userID && !recipe.IsListed() {
+		return errors.New("unauthorized to access this recipe")
+	}
+
+	return nil
+}
+
+// daysBetween counts the calendar days from one date to another, whatever
+// their time zones and daylight saving changes in between.
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// mealKey identifies a planned meal by its day, meal type and recipe.
+func mealKey(date time.Time, mealType string, recipeID uuid.UUID) string {
+	return date.Format(dateLayout) + "/" + mealType + "/" + recipeID.String()
+}
+
+// parseDateRange parses an inclusive date range, defaulting to the current
+// week (Monday to Sunday) when no start is given and to a week after the
+// start when no end is given.
+func parseDateRange(from, to string) (time.Time, time.Time, error) {
+	var fromDate time.Time
+	if from == "" {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		fromDate = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	} else {
+		date, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid date")
+		}
+		fromDate = date
+	}
+
+	toDate := fromDate.AddDate(0, 0, 6)
+	if to != "" {
+		date, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid date")
+		}
+		toDate = date
+	}
+
+	if toDate.Before(fromDate) || toDate.Sub(fromDate).Hours()/24 > maxMealPlanDays {
+		return time.Time{}, time.Time{}, errors.New("invalid date range")
+	}
+
+	return fromDate, toDate, nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
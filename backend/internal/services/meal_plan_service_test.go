@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type fakeMealPlanRepository struct {
+	repositories.MealPlanRepository
+	entries []models.MealPlanEntry
+}
+
+func (r *fakeMealPlanRepository) Create(entry *models.MealPlanEntry) error {
+	entry.ID = uuid.New()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakeMealPlanRepository) CreateBatch(entries []models.MealPlanEntry) error {
+	for i := range entries {
+		if err := r.Create(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeMealPlanRepository) GetByID(id uuid.UUID) (*models.MealPlanEntry, error) {
+	for _, entry := range r.entries {
+		if entry.ID == id {
+			return &entry, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeMealPlanRepository) GetByDateRange(userID uuid.UUID, from, to time.Time) ([]models.MealPlanEntry, error) {
+	var entries []models.MealPlanEntry
+	for _, entry := range r.entries {
+		if entry.UserID == userID && !entry.Date.Before(from) && !entry.Date.After(to) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+	return entries, nil
+}
+
+func day(date string) time.Time {
+	parsed, err := time.Parse(dateLayout, date)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// plannedDays lists the entries of a plan as "date meal_type".
+func plannedDays(entries []models.MealPlanEntry) []string {
+	var days []string
+	for _, entry := range entries {
+		days = append(days, entry.Date.Format(dateLayout)+" "+entry.MealType)
+	}
+	return days
+}
+
+func TestRepeatWeek(t *testing.T) {
+	owner := uuid.New()
+	recipe := uuid.New()
+	repo := &fakeMealPlanRepository{entries: []models.MealPlanEntry{
+		{ID: uuid.New(), UserID: owner, RecipeID: recipe, Date: day("2026-10-05"), MealType: "dinner"},
+		{ID: uuid.New(), UserID: owner, RecipeID: recipe, Date: day("2026-10-11"), MealType: "lunch"},
+		// Outside the source week
+		{ID: uuid.New(), UserID: owner, RecipeID: recipe, Date: day("2026-10-12"), MealType: "breakfast"},
+		// Someone else's week
+		{ID: uuid.New(), UserID: uuid.New(), RecipeID: recipe, Date: day("2026-10-06"), MealType: "dinner"},
+	}}
+	service := NewMealPlanService(repo, nil, nil, nil, "", "")
+	want := []string{"2026-10-12 breakfast", "2026-10-19 dinner", "2026-10-25 lunch"}
+
+	req := &models.MealPlanRepeatWeekRequest{FromWeek: "2026-10-05", ToWeek: "2026-10-19"}
+	for i := 0; i < 2; i++ {
+		plan, err := service.RepeatWeek(owner, req)
+		if err != nil {
+			t.Fatalf("RepeatWeek() error = %v", err)
+		}
+		if got := plannedDays(plan.Entries); len(got) != 2 || got[0] != want[1] || got[1] != want[2] {
+			t.Errorf("repeat %d planned %q, want %q", i+1, got, want[1:])
+		}
+	}
+	if len(repo.entries) != 6 {
+		t.Errorf("stored %d entries after repeating twice, want 6", len(repo.entries))
+	}
+
+	for _, req := range []*models.MealPlanRepeatWeekRequest{
+		{FromWeek: "2026-10-05", ToWeek: "2026-10-05"},
+		{FromWeek: "2026-10-05", ToWeek: "next week"},
+	} {
+		if _, err := service.RepeatWeek(owner, req); err == nil {
+			t.Errorf("RepeatWeek(%s, %s) succeeded, want an error", req.FromWeek, req.ToWeek)
+		}
+	}
+}
+
+func TestCopyEntry(t *testing.T) {
+	owner := uuid.New()
+	servings := 3
+	entry := models.MealPlanEntry{ID: uuid.New(), UserID: owner, RecipeID: uuid.New(), Date: day("2026-10-05"), MealType: "dinner", Servings: &servings}
+	repo := &fakeMealPlanRepository{entries: []models.MealPlanEntry{entry}}
+	service := NewMealPlanService(repo, nil, nil, nil, "", "")
+
+	lunch := "lunch"
+	copied, err := service.CopyEntry(owner, entry.ID, &models.MealPlanEntryMoveRequest{Date: "2026-10-07", MealType: &lunch})
+	if err != nil {
+		t.Fatalf("CopyEntry() error = %v", err)
+	}
+	if copied.ID == entry.ID || copied.RecipeID != entry.RecipeID || copied.Servings == nil || *copied.Servings != servings {
+		t.Errorf("copy = %+v, want a new entry for the same recipe and servings", copied)
+	}
+	if got := plannedDays([]models.MealPlanEntry{*copied}); got[0] != "2026-10-07 lunch" {
+		t.Errorf("copy planned for %q, want 2026-10-07 lunch", got[0])
+	}
+	if len(repo.entries) != 2 || repo.entries[0].Date != entry.Date {
+		t.Errorf("entries after copying = %q, want the original left in place", plannedDays(repo.entries))
+	}
+
+	if _, err := service.CopyEntry(uuid.New(), entry.ID, &models.MealPlanEntryMoveRequest{Date: "2026-10-07"}); err == nil {
+		t.Error("copying someone else's entry succeeded")
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// Clocks go back on 2026-11-01, so the week is 169 hours long
+	from := time.Date(2026, 10, 26, 0, 0, 0, 0, newYork)
+	to := time.Date(2026, 11, 2, 0, 0, 0, 0, newYork)
+	if got := daysBetween(from, to); got != 7 {
+		t.Errorf("daysBetween across the end of daylight saving = %d, want 7", got)
+	}
+
+	// Clocks go forward on 2026-03-08, so the week is 167 hours long
+	from = time.Date(2026, 3, 2, 0, 0, 0, 0, newYork)
+	to = time.Date(2026, 3, 9, 0, 0, 0, 0, newYork)
+	if got := daysBetween(from, to); got != 7 {
+		t.Errorf("daysBetween across the start of daylight saving = %d, want 7", got)
+	}
+}
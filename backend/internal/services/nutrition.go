@@ -0,0 +1,76 @@
+package services
+
+import (
+	"math"
+	"yummio-backend/internal/food"
+	"yummio-backend/internal/models"
+)
+
+// calculateNutrition estimates per-serving nutrition from a recipe's
+// ingredients, with sub-recipes expanded, using the bundled dataset.
+// Ingredients that aren't in the dataset or have no amount that converts
+// to grams are listed in UnmatchedIngredients, so partial totals are never
+// passed off as complete. Without a serving count there's nothing to
+// divide by, so the values are left unset rather than reporting the whole
+// recipe as one serving.
+func calculateNutrition(recipe *models.Recipe, ingredients []models.Ingredient) *models.Nutrition {
+	var total food.Nutrients
+	unmatched := []string{}
+
+	for _, ingredient := range ingredients {
+		if ingredient.Amount == nil {
+			unmatched = append(unmatched, ingredient.Name)
+			continue
+		}
+
+		unit := ""
+		if ingredient.Unit != nil {
+			unit = *ingredient.Unit
+		}
+
+		nutrients, ok := food.IngredientNutrients(ingredient.Name, *ingredient.Amount, unit)
+		if !ok {
+			unmatched = append(unmatched, ingredient.Name)
+			continue
+		}
+		total.Add(nutrients)
+	}
+
+	if recipe.Servings == nil || *recipe.Servings <= 0 {
+		return &models.Nutrition{Calculated: true, UnmatchedIngredients: unmatched}
+	}
+	total = total.Scale(1 / float64(*recipe.Servings))
+
+	calories := int(math.Round(total.Calories))
+	return &models.Nutrition{
+		Calories:             &calories,
+		Protein:              roundNutrient(total.Protein),
+		Carbs:                roundNutrient(total.Carbs),
+		Fat:                  roundNutrient(total.Fat),
+		Fiber:                roundNutrient(total.Fiber),
+		Sugar:                roundNutrient(total.Sugar),
+		Sodium:               roundNutrient(total.Sodium),
+		Cholesterol:          roundNutrient(total.Cholesterol),
+		Calculated:           true,
+		UnmatchedIngredients: unmatched,
+	}
+}
+
+// setCalculatedNutrition keeps estimated nutrition in step with a recipe's
+// ingredients, but never overwrites values the author entered.
+func setCalculatedNutrition(recipe *models.Recipe, ingredients []models.Ingredient) {
+	if len(recipe.Ingredients) == 0 || (recipe.Nutrition != nil && !recipe.Nutrition.Calculated) {
+		return
+	}
+
+	calculated := calculateNutrition(recipe, ingredients)
+	if recipe.Nutrition != nil {
+		calculated.ID = recipe.Nutrition.ID
+	}
+	recipe.Nutrition = calculated
+}
+
+func roundNutrient(value float64) *float64 {
+	rounded := math.Round(value*10) / 10
+	return &rounded
+}
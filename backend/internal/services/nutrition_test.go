@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"yummio-backend/internal/models"
+)
+
+func TestCalculateNutritionServings(t *testing.T) {
+	amount, unit := 200.0, "g"
+	ingredients := []models.Ingredient{{Name: "flour", Amount: &amount, Unit: &unit}}
+	servings := func(n int) *int { return &n }
+
+	whole := calculateNutrition(&models.Recipe{Servings: servings(1)}, ingredients)
+	if whole.Calories == nil || *whole.Calories == 0 {
+		t.Fatalf("calories for one serving = %v, want an estimate", whole.Calories)
+	}
+
+	halved := calculateNutrition(&models.Recipe{Servings: servings(2)}, ingredients)
+	if halved.Calories == nil || *halved.Calories*2-*whole.Calories > 1 || *whole.Calories-*halved.Calories*2 > 1 {
+		t.Errorf("calories for two servings = %v, want about half of %d", halved.Calories, *whole.Calories)
+	}
+
+	for _, recipe := range []*models.Recipe{{}, {Servings: servings(0)}} {
+		got := calculateNutrition(recipe, ingredients)
+		if !got.Calculated {
+			t.Error("nutrition without servings isn't marked as calculated")
+		}
+		if got.Calories != nil || got.Protein != nil || got.Sodium != nil {
+			t.Errorf("nutrition without servings = %+v, want per-serving values unset", got)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"yummio-backend/internal/models"
+)
+
+// diffRecipes lists the changes between two revisions.
+func diffRecipes(from, to *models.RecipeRevision) *models.RecipeDiff {
+	a, b := from.Snapshot, to.Snapshot
+	diff := &models.RecipeDiff{
+		From:         from.Number,
+		To:           to.Number,
+		Fields:       []models.FieldChange{},
+		Ingredients:  diffIngredients(a.Ingredients, b.Ingredients),
+		Instructions: diffInstructions(a.Instructions, b.Instructions, a.Ingredients, b.Ingredients),
+		TagsAdded:    []string{},
+		TagsRemoved:  []string{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"title", a.Title, b.Title},
+		{"description", a.Description, b.Description},
+		{"image_url", a.ImageURL, b.ImageURL},
+		{"prep_time", a.PrepTime, b.PrepTime},
+		{"cook_time", a.CookTime, b.CookTime},
+		{"servings", a.Servings, b.Servings},
+		{"difficulty", a.Difficulty, b.Difficulty},
+		{"type", a.Type, b.Type},
+		{"is_public", a.IsPublic, b.IsPublic},
+		{"source_url", a.SourceURL, b.SourceURL},
+	}
+
+	na, nb := a.Nutrition, b.Nutrition
+	if na == nil {
+		na = &models.NutritionCreateRequest{}
+	}
+	if nb == nil {
+		nb = &models.NutritionCreateRequest{}
+	}
+	fields = append(fields, []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"nutrition.calories", na.Calories, nb.Calories},
+		{"nutrition.protein", na.Protein, nb.Protein},
+		{"nutrition.carbs", na.Carbs, nb.Carbs},
+		{"nutrition.fat", na.Fat, nb.Fat},
+		{"nutrition.fiber", na.Fiber, nb.Fiber},
+		{"nutrition.sugar", na.Sugar, nb.Sugar},
+		{"nutrition.sodium", na.Sodium, nb.Sodium},
+		{"nutrition.cholesterol", na.Cholesterol, nb.Cholesterol},
+	}...)
+
+	for _, field := range fields {
+		fromValue, toValue := fieldValue(field.from), fieldValue(field.to)
+		if !reflect.DeepEqual(fromValue, toValue) {
+			diff.Fields = append(diff.Fields, models.FieldChange{Field: field.name, From: fromValue, To: toValue})
+		}
+	}
+
+	tags := make(map[string]bool)
+	for _, tag := range a.Tags {
+		tags[tag] = true
+	}
+	for _, tag := range b.Tags {
+		if tags[tag] {
+			delete(tags, tag)
+		} else {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+	for _, tag := range a.Tags {
+		if tags[tag] {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+
+	return diff
+}
+
+// fieldValue dereferences optional fields, so unset ones compare as nil
+// and set ones by value.
+func fieldValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return v
+	}
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Elem().Interface()
+}
+
+// diffIngredients matches ingredients by name, in order when a name appears
+// more than once, and reports the ones whose spelling, amount, unit, notes
+// or sub-recipe changed.
+func diffIngredients(from, to []models.IngredientCreateRequest) []models.IngredientChange {
+	changes := []models.IngredientChange{}
+
+	unmatched := make(map[string][]int)
+	for i, ingredient := range from {
+		key := ingredientKey(ingredient.Name)
+		unmatched[key] = append(unmatched[key], i)
+	}
+
+	matched := make([]bool, len(from))
+	for i := range to {
+		ingredient := &to[i]
+		key := ingredientKey(ingredient.Name)
+		if len(unmatched[key]) == 0 {
+			changes = append(changes, models.IngredientChange{Change: "added", Name: ingredient.Name, To: ingredient})
+			continue
+		}
+
+		j := unmatched[key][0]
+		unmatched[key] = unmatched[key][1:]
+		matched[j] = true
+
+		old := &from[j]
+		if old.Name != ingredient.Name ||
+			!reflect.DeepEqual(fieldValue(old.Amount), fieldValue(ingredient.Amount)) ||
+			!reflect.DeepEqual(fieldValue(old.Unit), fieldValue(ingredient.Unit)) ||
+			!reflect.DeepEqual(fieldValue(old.Notes), fieldValue(ingredient.Notes)) ||
+			!reflect.DeepEqual(fieldValue(old.SubRecipeID), fieldValue(ingredient.SubRecipeID)) {
+			changes = append(changes, models.IngredientChange{Change: "changed", Name: ingredient.Name, From: old, To: ingredient})
+		}
+	}
+
+	for j := range from {
+		if !matched[j] {
+			changes = append(changes, models.IngredientChange{Change: "removed", Name: from[j].Name, From: &from[j]})
+		}
+	}
+	return changes
+}
+
+// diffInstructions aligns the steps of two revisions on their longest
+// common run of unchanged texts. Steps left over between two aligned ones
+// are paired up as rewordings, and the rest were added or removed. The
+// ingredients of each revision tell which ones its steps use.
+func diffInstructions(from, to []models.InstructionCreateRequest, fromIngredients, toIngredients []models.IngredientCreateRequest) []models.InstructionChange {
+	changes := []models.InstructionChange{}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if stepKey(from[i].Instruction) == stepKey(to[j].Instruction) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var removed, added []int
+	flush := func() {
+		for len(removed) > 0 && len(added) > 0 {
+			changes = append(changes, models.InstructionChange{Change: "reworded", From: &from[removed[0]], To: &to[added[0]]})
+			removed, added = removed[1:], added[1:]
+		}
+		for _, i := range removed {
+			changes = append(changes, models.InstructionChange{Change: "removed", From: &from[i]})
+		}
+		for _, j := range added {
+			changes = append(changes, models.InstructionChange{Change: "added", To: &to[j]})
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && stepKey(from[i].Instruction) == stepKey(to[j].Instruction):
+			flush()
+			if !reflect.DeepEqual(fieldValue(from[i].TimerMinutes), fieldValue(to[j].TimerMinutes)) ||
+				!reflect.DeepEqual(fieldValue(from[i].ImageURL), fieldValue(to[j].ImageURL)) ||
+				!reflect.DeepEqual(stepUses(from[i], fromIngredients), stepUses(to[j], toIngredients)) {
+				changes = append(changes, models.InstructionChange{Change: "changed", From: &from[i], To: &to[j]})
+			}
+			i++
+			j++
+		case j == len(to) || (i < len(from) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, i)
+			i++
+		default:
+			added = append(added, j)
+			j++
+		}
+	}
+	flush()
+
+	return changes
+}
+
+func stepKey(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// stepUses describes the ingredients a step uses by name rather than
+// position, so steps don't change when ingredients are added before theirs.
+func stepUses(step models.InstructionCreateRequest, ingredients []models.IngredientCreateRequest) []string {
+	var uses []string
+	for _, link := range step.Ingredients {
+		name := ""
+		if link.Ingredient != nil && *link.Ingredient >= 0 && *link.Ingredient < len(ingredients) {
+			name = ingredientKey(ingredients[*link.Ingredient].Name)
+		}
+		uses = append(uses, fmt.Sprintf("%s %v %v", name, fieldValue(link.Amount), fieldValue(link.Unit)))
+	}
+	return uses
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"yummio-backend/internal/models"
+)
+
+// diffSummary writes a diff as one line per change, for comparison.
+func diffSummary(diff *models.RecipeDiff) []string {
+	var lines []string
+	for _, field := range diff.Fields {
+		lines = append(lines, fmt.Sprintf("field %s: %v -> %v", field.Field, field.From, field.To))
+	}
+	for _, change := range diff.Ingredients {
+		lines = append(lines, fmt.Sprintf("ingredient %s %s", change.Change, change.Name))
+	}
+	for _, change := range diff.Instructions {
+		from, to := 0, 0
+		if change.From != nil {
+			from = change.From.Step
+		}
+		if change.To != nil {
+			to = change.To.Step
+		}
+		lines = append(lines, fmt.Sprintf("step %s %d -> %d", change.Change, from, to))
+	}
+	for _, tag := range diff.TagsAdded {
+		lines = append(lines, "tag added "+tag)
+	}
+	for _, tag := range diff.TagsRemoved {
+		lines = append(lines, "tag removed "+tag)
+	}
+	return lines
+}
+
+func TestDiffRecipes(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	integer := func(i int) *int { return &i }
+	boolean := func(b bool) *bool { return &b }
+
+	base := func() *models.RecipeCreateRequest {
+		return &models.RecipeCreateRequest{
+			Title:    "Pancakes",
+			Servings: integer(4),
+			IsPublic: boolean(true),
+			Tags:     []string{"breakfast", "sweet"},
+			Ingredients: []models.IngredientCreateRequest{
+				{Name: "flour", Amount: num(1.5), Unit: str("cup")},
+				{Name: "eggs", Amount: num(2)},
+				{Name: "milk", Amount: num(1), Unit: str("cup")},
+			},
+			Instructions: []models.InstructionCreateRequest{
+				{Step: 1, Instruction: "Whisk the flour and eggs."},
+				{Step: 2, Instruction: "Stir in the milk."},
+				{Step: 3, Instruction: "Cook on a hot griddle.", TimerMinutes: integer(3)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *models.RecipeCreateRequest)
+		want   []string
+	}{
+		{
+			name:   "unchanged",
+			change: func(r *models.RecipeCreateRequest) {},
+		},
+		{
+			name: "fields",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Title = "Fluffy Pancakes"
+				r.Description = str("Light and tall.")
+				r.Servings = integer(6)
+				r.IsPublic = boolean(false)
+				r.Nutrition = &models.NutritionCreateRequest{Calories: integer(300)}
+			},
+			want: []string{
+				"field title: Pancakes -> Fluffy Pancakes",
+				"field description: <nil> -> Light and tall.",
+				"field servings: 4 -> 6",
+				"field is_public: true -> false",
+				"field nutrition.calories: <nil> -> 300",
+			},
+		},
+		{
+			name: "ingredients matched by name",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Ingredients = []models.IngredientCreateRequest{
+					{Name: "butter", Amount: num(2), Unit: str("tbsp")},
+					{Name: "Flour", Amount: num(1.5), Unit: str("cup")},
+					{Name: "egg", Amount: num(3)},
+				}
+			},
+			want: []string{
+				"ingredient added butter",
+				"ingredient changed Flour",
+				"ingredient changed egg",
+				"ingredient removed milk",
+			},
+		},
+		{
+			name: "ingredient reordered",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Ingredients[0], r.Ingredients[2] = r.Ingredients[2], r.Ingredients[0]
+			},
+		},
+		{
+			name: "step inserted",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Instructions = []models.InstructionCreateRequest{
+					r.Instructions[0],
+					{Step: 2, Instruction: "Rest the batter."},
+					{Step: 3, Instruction: r.Instructions[1].Instruction},
+					{Step: 4, Instruction: r.Instructions[2].Instruction, TimerMinutes: r.Instructions[2].TimerMinutes},
+				}
+			},
+			want: []string{"step added 0 -> 2"},
+		},
+		{
+			name: "step reworded, retimed and removed",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Instructions = []models.InstructionCreateRequest{
+					{Step: 1, Instruction: "Whisk  the flour and eggs. "},
+					{Step: 2, Instruction: "Cook on a hot griddle.", TimerMinutes: integer(4)},
+				}
+			},
+			want: []string{
+				"step removed 2 -> 0",
+				"step changed 3 -> 2",
+			},
+		},
+		{
+			name: "step reworded",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Instructions[1].Instruction = "Slowly stir in the milk."
+			},
+			want: []string{"step reworded 2 -> 2"},
+		},
+		{
+			name: "tags",
+			change: func(r *models.RecipeCreateRequest) {
+				r.Tags = []string{"sweet", "weekend"}
+			},
+			want: []string{"tag added weekend", "tag removed breakfast"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := base()
+			tt.change(to)
+			diff := diffRecipes(
+				&models.RecipeRevision{Number: 1, Snapshot: base()},
+				&models.RecipeRevision{Number: 2, Snapshot: to},
+			)
+
+			if diff.From != 1 || diff.To != 2 {
+				t.Errorf("diff is from %d to %d, want 1 to 2", diff.From, diff.To)
+			}
+			if got := diffSummary(diff); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStepIngredients(t *testing.T) {
+	num := func(f float64) *float64 { return &f }
+	revision := func(number int, ingredients []string, uses int, amount float64) *models.RecipeRevision {
+		snapshot := &models.RecipeCreateRequest{Title: "Soup"}
+		for _, name := range ingredients {
+			snapshot.Ingredients = append(snapshot.Ingredients, models.IngredientCreateRequest{Name: name})
+		}
+		snapshot.Instructions = []models.InstructionCreateRequest{{
+			Step:        1,
+			Instruction: "Simmer the stock.",
+			Ingredients: []models.StepIngredientRequest{{Ingredient: &uses, Amount: num(amount)}},
+		}}
+		return &models.RecipeRevision{Number: number, Snapshot: snapshot}
+	}
+
+	tests := []struct {
+		name string
+		to   *models.RecipeRevision
+		want []string
+	}{
+		{
+			name: "ingredient added before the linked one",
+			to:   revision(2, []string{"salt", "stock"}, 1, 2),
+			want: []string{"ingredient added salt"},
+		},
+		{
+			name: "linked amount changed",
+			to:   revision(2, []string{"stock"}, 0, 3),
+			want: []string{"step changed 1 -> 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffRecipes(revision(1, []string{"stock"}, 0, 2), tt.to)
+			if got := diffSummary(diff); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ForkRecipe copies a public recipe, or one of the user's own, into the
+// user's account as a private recipe crediting the original.
+func (s *recipeService) ForkRecipe(userID, recipeID uuid.UUID) (*models.Recipe, error) {
+	original, err := s.recipeRepo.GetByID(recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !original.IsListed() && original.UserID != userID {
+		return nil, errors.New("unauthorized to fork this recipe")
+	}
+
+	req := snapshotRecipe(original)
+	isPublic := false
+	req.IsPublic = &isPublic
+	s.dropUnusableSubRecipes(userID, req.Ingredients)
+
+	fork, err := s.createRecipe(userID, req, &original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recipeRepo.UpdateForkCount(original.ID); err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// GetForks lists the public forks of a recipe.
+func (s *recipeService) GetForks(recipeID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error) {
+	return s.recipeRepo.GetForks(recipeID, query)
+}
+
+// fillForkedFrom credits the original of a forked recipe. Nothing is set
+// once the original has been deleted.
+func (s *recipeService) fillForkedFrom(recipe *models.Recipe) {
+	if recipe.ForkedFromID == nil {
+		return
+	}
+
+	original, err := s.recipeRepo.GetByID(*recipe.ForkedFromID)
+	if err != nil {
+		return
+	}
+
+	recipe.ForkedFrom = &models.RecipeAttribution{
+		ID:         original.ID,
+		Title:      original.Title,
+		AuthorID:   original.UserID,
+		AuthorName: original.User.Name,
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"yummio-backend/internal/importer"
+	"yummio-backend/internal/ingredients"
+	"yummio-backend/internal/models"
+)
+
+// maxImportPageSize bounds how much of a fetched page is read.
+const maxImportPageSize = 5 << 20
+
+// importClient fetches recipe pages. It refuses to connect to loopback,
+// private and link-local addresses so imports can't be used to probe the
+// internal network.
+var importClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: publicAddressOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return nil
+	},
+}
+
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errors.New("address not allowed")
+	}
+	return nil
+}
+
+func (s *recipeService) PreviewImport(req *models.RecipeImportRequest) (*models.RecipeImportPreview, error) {
+	var page io.Reader = strings.NewReader(req.HTML)
+	if req.HTML == "" {
+		body, err := fetchRecipePage(req.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		page = body
+	}
+
+	recipe, warnings, err := importer.Extract(page, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return &models.RecipeImportPreview{
+		Recipe:   *recipe,
+		Warnings: warnings,
+	}, nil
+}
+
+// fetchRecipePage downloads a page to import. The caller closes the body.
+func fetchRecipePage(pageURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("invalid recipe URL")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.New("invalid recipe URL")
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; YummioRecipeImporter/1.0)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := importClient.Do(req)
+	if err != nil {
+		return nil, errors.New("could not fetch recipe page")
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New("could not fetch recipe page")
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, maxImportPageSize), resp.Body}, nil
+}
+
+// ParseIngredients parses free-text ingredient lines in order, skipping
+// blank ones.
+func (s *recipeService) ParseIngredients(lines []string) []models.IngredientCreateRequest {
+	parsed := []models.IngredientCreateRequest{}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ingredient := ingredients.Parse(line)
+		order := len(parsed)
+		ingredient.OrderIndex = &order
+		parsed = append(parsed, ingredient)
+	}
+	return parsed
+}
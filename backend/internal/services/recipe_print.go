@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"yummio-backend/internal/exporter"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// maxPrintImageSize bounds how much of a recipe image is downloaded for a
+// PDF.
+const maxPrintImageSize = 10 << 20
+
+// maxPrintImagePixels bounds the dimensions of a recipe image decoded for a
+// PDF. A small file can claim a huge size, and decoding allocates for it.
+const maxPrintImagePixels = 4096
+
+// printImageWorkers is how many recipe images of a booklet are downloaded
+// at once.
+const printImageWorkers = 4
+
+// PrintRecipes renders recipes for printing. PDFs embed the recipe images,
+// which are downloaded here; images that can't be loaded are left out.
+func (s *recipeService) PrintRecipes(book *exporter.Booklet, format exporter.Format, opts exporter.PrintOptions) ([]byte, error) {
+	if format == exporter.PDF {
+		book.Images = loadPrintImages(book.Recipes)
+	}
+	return exporter.Print(book, format, opts)
+}
+
+func loadPrintImages(recipes []*models.Recipe) map[uuid.UUID]image.Image {
+	images := make(map[uuid.UUID]image.Image)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan *models.Recipe)
+
+	for i := 0; i < printImageWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for recipe := range queue {
+				img, err := fetchPrintImage(*recipe.ImageURL)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				images[recipe.ID] = img
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, recipe := range recipes {
+		if recipe.ImageURL != nil && *recipe.ImageURL != "" {
+			queue <- recipe
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return images
+}
+
+// fetchPrintImage downloads and decodes a recipe image. It uses the import
+// client, so only public addresses are reached.
+func fetchPrintImage(imageURL string) (image.Image, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("invalid image URL")
+	}
+
+	resp, err := importClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("could not fetch image")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPrintImageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxPrintImagePixels || config.Height > maxPrintImagePixels {
+		return nil, errors.New("image is too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+	"yummio-backend/internal/models"
+)
+
+// publishInterval is how often scheduled drafts are checked. A recipe goes
+// public within this long of its publish time.
+const publishInterval = time.Minute
+
+// applyStatus sets a recipe's status and publish time from a request. A
+// request without a status keeps the current one, so older clients that
+// don't know about drafts leave them alone.
+func applyStatus(recipe *models.Recipe, req *models.RecipeCreateRequest) error {
+	changed := req.Status != nil
+	if changed {
+		recipe.Status = *req.Status
+	}
+
+	if req.PublishAt != nil {
+		if recipe.Status != models.RecipeStatusDraft {
+			return errors.New("publish_at can only be set on drafts")
+		}
+		if !req.PublishAt.After(time.Now()) {
+			return errors.New("publish_at must be in the future")
+		}
+		publishAt := req.PublishAt.UTC()
+		recipe.PublishAt = &publishAt
+	} else if changed || recipe.Status != models.RecipeStatusDraft {
+		recipe.PublishAt = nil
+	}
+	return nil
+}
+
+// StartScheduledPublishing publishes scheduled drafts in the background as
+// their publish time passes.
+func (s *recipeService) StartScheduledPublishing() {
+	go func() {
+		ticker := time.NewTicker(publishInterval)
+		defer ticker.Stop()
+
+		for {
+			s.publishScheduled()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *recipeService) publishScheduled() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Publishing scheduled recipes panicked: %v", r)
+		}
+	}()
+
+	count, err := s.recipeRepo.PublishScheduled(time.Now())
+	if err != nil {
+		log.Printf("Failed to publish scheduled recipes: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Published %d scheduled recipes", count)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"yummio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// maxRecipeRevisions is how many revisions are kept per recipe. Older ones
+// are deleted as new ones are saved.
+const maxRecipeRevisions = 100
+
+// newRevision captures a recipe about to be saved as its next revision. The
+// repository numbers and stores it along with the recipe.
+func newRevision(userID uuid.UUID, recipe *models.Recipe, restoredFrom *int) *models.RecipeRevision {
+	return &models.RecipeRevision{
+		RecipeID:     recipe.ID,
+		UserID:       userID,
+		Title:        recipe.Title,
+		RestoredFrom: restoredFrom,
+		Snapshot:     snapshotRecipe(recipe),
+	}
+}
+
+// baseRevision captures the current state of a recipe before an update. It
+// is only stored for recipes saved before revisions were kept, so their
+// first update can be undone too.
+func baseRevision(recipe *models.Recipe) *models.RecipeRevision {
+	return &models.RecipeRevision{
+		RecipeID:  recipe.ID,
+		UserID:    recipe.UserID,
+		Title:     recipe.Title,
+		Snapshot:  snapshotRecipe(recipe),
+		CreatedAt: recipe.UpdatedAt,
+	}
+}
+
+// pruneRevisions deletes a recipe's oldest revisions beyond the ones kept.
+// The recipe is saved by then, so a failure is only logged.
+func (s *recipeService) pruneRevisions(recipeID uuid.UUID) {
+	if err := s.revisionRepo.Prune(recipeID, maxRecipeRevisions); err != nil {
+		log.Printf("Failed to prune revisions of recipe %s: %v", recipeID, err)
+	}
+}
+
+// snapshotRecipe captures a recipe in the shape of an update request, so a
+// revision is restored by saving it again. Estimated nutrition is left out
+// and calculated again on restore.
+func snapshotRecipe(recipe *models.Recipe) *models.RecipeCreateRequest {
+	isPublic := recipe.IsPublic
+	snapshot := &models.RecipeCreateRequest{
+		Title:       recipe.Title,
+		Description: recipe.Description,
+		ImageURL:    recipe.ImageURL,
+		PrepTime:    recipe.PrepTime,
+		CookTime:    recipe.CookTime,
+		Servings:    recipe.Servings,
+		Difficulty:  recipe.Difficulty,
+		Type:        recipe.Type,
+		IsPublic:    &isPublic,
+		SourceURL:   recipe.SourceURL,
+	}
+
+	ingredients := append([]models.Ingredient(nil), recipe.Ingredients...)
+	sort.SliceStable(ingredients, func(i, j int) bool {
+		return ingredients[i].OrderIndex < ingredients[j].OrderIndex
+	})
+	positions := make(map[uuid.UUID]int, len(ingredients))
+	for i, ingredient := range ingredients {
+		positions[ingredient.ID] = i
+		order := ingredient.OrderIndex
+		snapshot.Ingredients = append(snapshot.Ingredients, models.IngredientCreateRequest{
+			Name:        ingredient.Name,
+			Amount:      ingredient.Amount,
+			Unit:        ingredient.Unit,
+			Notes:       ingredient.Notes,
+			SubRecipeID: ingredient.SubRecipeID,
+			OrderIndex:  &order,
+		})
+	}
+
+	instructions := append([]models.Instruction(nil), recipe.Instructions...)
+	sort.SliceStable(instructions, func(i, j int) bool {
+		return instructions[i].Step < instructions[j].Step
+	})
+	for _, instruction := range instructions {
+		step := models.InstructionCreateRequest{
+			Step:         instruction.Step,
+			Instruction:  instruction.Instruction,
+			ImageURL:     instruction.ImageURL,
+			TimerMinutes: instruction.TimerMinutes,
+		}
+		for _, link := range instruction.Ingredients {
+			if position, ok := positions[link.IngredientID]; ok {
+				step.Ingredients = append(step.Ingredients, models.StepIngredientRequest{
+					Ingredient: &position,
+					Amount:     link.Amount,
+					Unit:       link.Unit,
+				})
+			}
+		}
+		snapshot.Instructions = append(snapshot.Instructions, step)
+	}
+
+	for _, tag := range recipe.Tags {
+		snapshot.Tags = append(snapshot.Tags, tag.Name)
+	}
+
+	if n := recipe.Nutrition; n != nil && !n.Calculated {
+		snapshot.Nutrition = &models.NutritionCreateRequest{
+			Calories:    n.Calories,
+			Protein:     n.Protein,
+			Carbs:       n.Carbs,
+			Fat:         n.Fat,
+			Fiber:       n.Fiber,
+			Sugar:       n.Sugar,
+			Sodium:      n.Sodium,
+			Cholesterol: n.Cholesterol,
+		}
+	}
+
+	return snapshot
+}
+
+// ownRecipe loads a recipe for its owner.
+func (s *recipeService) ownRecipe(userID, recipeID uuid.UUID) (*models.Recipe, error) {
+	recipe, err := s.recipeRepo.GetByID(recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if recipe.UserID != userID {
+		return nil, errors.New("unauthorized to access this recipe")
+	}
+	return recipe, nil
+}
+
+func (s *recipeService) getRevision(recipeID uuid.UUID, number int) (*models.RecipeRevision, error) {
+	revision, err := s.revisionRepo.GetByNumber(recipeID, number)
+	if err != nil {
+		return nil, errors.New("revision not found")
+	}
+	return revision, nil
+}
+
+func (s *recipeService) GetRevisions(userID, recipeID uuid.UUID) ([]models.RecipeRevision, error) {
+	if _, err := s.ownRecipe(userID, recipeID); err != nil {
+		return nil, err
+	}
+	return s.revisionRepo.GetByRecipeID(recipeID)
+}
+
+func (s *recipeService) GetRevision(userID, recipeID uuid.UUID, number int) (*models.RecipeRevision, error) {
+	if _, err := s.ownRecipe(userID, recipeID); err != nil {
+		return nil, err
+	}
+	return s.getRevision(recipeID, number)
+}
+
+// DiffRevisions compares revision against to revision number. Against 0
+// compares with an empty recipe, listing everything as added.
+func (s *recipeService) DiffRevisions(userID, recipeID uuid.UUID, against, number int) (*models.RecipeDiff, error) {
+	if _, err := s.ownRecipe(userID, recipeID); err != nil {
+		return nil, err
+	}
+
+	to, err := s.getRevision(recipeID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	from := &models.RecipeRevision{Number: 0, Snapshot: &models.RecipeCreateRequest{}}
+	if against > 0 {
+		if from, err = s.getRevision(recipeID, against); err != nil {
+			return nil, err
+		}
+	}
+
+	return diffRecipes(from, to), nil
+}
+
+// RestoreRevision saves a past revision as the recipe's current state. This
+// adds a new revision, so the restore can be undone as well.
+func (s *recipeService) RestoreRevision(userID, recipeID uuid.UUID, number int) (*models.Recipe, error) {
+	if _, err := s.ownRecipe(userID, recipeID); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.getRevision(recipeID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dropUnusableSubRecipes(userID, revision.Snapshot.Ingredients)
+	return s.updateRecipe(userID, recipeID, revision.Snapshot, &revision.Number)
+}
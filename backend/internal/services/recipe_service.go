@@ -2,8 +2,13 @@ package services
 
 import (
 	"errors"
+	"sort"
+	"strings"
+	"yummio-backend/internal/exporter"
+	"yummio-backend/internal/food"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/repositories"
+	"yummio-backend/internal/units"
 
 	"github.com/google/uuid"
 )
@@ -11,54 +16,103 @@ import (
 type RecipeService interface {
 	CreateRecipe(userID uuid.UUID, req *models.RecipeCreateRequest) (*models.Recipe, error)
 	GetRecipe(id uuid.UUID) (*models.Recipe, error)
+	GetScaledRecipe(id uuid.UUID, servings int) (*models.Recipe, error)
+	ScaleRecipe(recipe *models.Recipe, servings int) error
+	ConvertRecipe(recipe *models.Recipe, system units.System)
 	GetRecipes(query *models.RecipeQuery) ([]models.Recipe, int64, error)
 	GetMyRecipes(userID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error)
 	UpdateRecipe(userID, recipeID uuid.UUID, req *models.RecipeCreateRequest) (*models.Recipe, error)
 	DeleteRecipe(userID, recipeID uuid.UUID) error
-	SearchRecipes(query *models.RecipeQuery) ([]models.Recipe, int64, error)
-	GetFeaturedRecipes(limit int) ([]models.Recipe, error)
+	SearchRecipes(query *models.RecipeQuery) ([]models.RecipeSearchResult, int64, error)
+	GetFeaturedRecipes(limit int, query *models.RecipeQuery) ([]models.Recipe, error)
+	Autocomplete(term string, limit int) (*models.AutocompleteResponse, error)
+	FindByIngredients(userID uuid.UUID, req *models.RecipesByIngredientsRequest) ([]models.RecipeMatch, int64, error)
+	BackfillDietaryLabels() error
+	PreviewImport(req *models.RecipeImportRequest) (*models.RecipeImportPreview, error)
+	ParseIngredients(lines []string) []models.IngredientCreateRequest
+	ExportRecipe(recipe *models.Recipe, format exporter.Format) ([]byte, error)
+	PrintRecipes(book *exporter.Booklet, format exporter.Format, opts exporter.PrintOptions) ([]byte, error)
+	CookMode(recipe *models.Recipe) *models.CookMode
+	GetRevisions(userID, recipeID uuid.UUID) ([]models.RecipeRevision, error)
+	GetRevision(userID, recipeID uuid.UUID, number int) (*models.RecipeRevision, error)
+	DiffRevisions(userID, recipeID uuid.UUID, against, number int) (*models.RecipeDiff, error)
+	RestoreRevision(userID, recipeID uuid.UUID, number int) (*models.Recipe, error)
+	ForkRecipe(userID, recipeID uuid.UUID) (*models.Recipe, error)
+	GetForks(recipeID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error)
+	StartScheduledPublishing()
 	FavoriteRecipe(userID, recipeID uuid.UUID) error
 	UnfavoriteRecipe(userID, recipeID uuid.UUID) error
 	GetFavorites(userID uuid.UUID, query *models.RecipeQuery) ([]models.Recipe, int64, error)
 	RateRecipe(userID, recipeID uuid.UUID, req *models.RateRecipeRequest) error
 }
 
+// maxIngredientCandidates bounds how many recipes are scored when searching
+// by available ingredients.
+const maxIngredientCandidates = 500
+
 type recipeService struct {
-	recipeRepo repositories.RecipeRepository
+	recipeRepo   repositories.RecipeRepository
+	userRepo     repositories.UserRepository
+	revisionRepo repositories.RecipeRevisionRepository
+	appURL       string
 }
 
-func NewRecipeService(recipeRepo repositories.RecipeRepository) RecipeService {
+func NewRecipeService(recipeRepo repositories.RecipeRepository, userRepo repositories.UserRepository, revisionRepo repositories.RecipeRevisionRepository, appURL string) RecipeService {
 	return &recipeService{
-		recipeRepo: recipeRepo,
+		recipeRepo:   recipeRepo,
+		userRepo:     userRepo,
+		revisionRepo: revisionRepo,
+		appURL:       appURL,
 	}
 }
 
 func (s *recipeService) CreateRecipe(userID uuid.UUID, req *models.RecipeCreateRequest) (*models.Recipe, error) {
+	return s.createRecipe(userID, req, nil)
+}
+
+// createRecipe saves a new recipe as its first revision. forkedFrom is set
+// when the request is a copy of another recipe.
+func (s *recipeService) createRecipe(userID uuid.UUID, req *models.RecipeCreateRequest, forkedFrom *uuid.UUID) (*models.Recipe, error) {
 	recipe := &models.Recipe{
-		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
-		ImageURL:    req.ImageURL,
-		PrepTime:    req.PrepTime,
-		CookTime:    req.CookTime,
-		Servings:    req.Servings,
-		Difficulty:  req.Difficulty,
-		Type:        req.Type,
-		IsPublic:    true,
+		UserID:       userID,
+		Title:        req.Title,
+		Description:  req.Description,
+		ImageURL:     req.ImageURL,
+		PrepTime:     req.PrepTime,
+		CookTime:     req.CookTime,
+		Servings:     req.Servings,
+		Difficulty:   req.Difficulty,
+		Type:         req.Type,
+		IsPublic:     true,
+		SourceURL:    req.SourceURL,
+		ForkedFromID: forkedFrom,
+		Status:       models.RecipeStatusPublished,
 	}
 
 	if req.IsPublic != nil {
 		recipe.IsPublic = *req.IsPublic
 	}
 
+	if err := applyStatus(recipe, req); err != nil {
+		return nil, err
+	}
+
 	// Add ingredients
+	if len(req.Ingredients) == 0 && len(req.Lines) > 0 {
+		req.Ingredients = s.ParseIngredients(req.Lines)
+	}
+	if err := s.checkSubRecipes(userID, uuid.Nil, req.Ingredients); err != nil {
+		return nil, err
+	}
 	for i, ingredientReq := range req.Ingredients {
 		ingredient := models.Ingredient{
-			Name:       ingredientReq.Name,
-			Amount:     ingredientReq.Amount,
-			Unit:       ingredientReq.Unit,
-			Notes:      ingredientReq.Notes,
-			OrderIndex: i,
+			ID:          uuid.New(),
+			Name:        ingredientReq.Name,
+			Amount:      ingredientReq.Amount,
+			Unit:        ingredientReq.Unit,
+			Notes:       ingredientReq.Notes,
+			SubRecipeID: ingredientReq.SubRecipeID,
+			OrderIndex:  i,
 		}
 		if ingredientReq.OrderIndex != nil {
 			ingredient.OrderIndex = *ingredientReq.OrderIndex
@@ -68,11 +122,16 @@ func (s *recipeService) CreateRecipe(userID uuid.UUID, req *models.RecipeCreateR
 
 	// Add instructions
 	for _, instructionReq := range req.Instructions {
+		links, err := stepIngredients(instructionReq.Ingredients, recipe.Ingredients)
+		if err != nil {
+			return nil, err
+		}
 		instruction := models.Instruction{
 			Step:         instructionReq.Step,
 			Instruction:  instructionReq.Instruction,
 			ImageURL:     instructionReq.ImageURL,
 			TimerMinutes: instructionReq.TimerMinutes,
+			Ingredients:  links,
 		}
 		recipe.Instructions = append(recipe.Instructions, instruction)
 	}
@@ -85,6 +144,9 @@ func (s *recipeService) CreateRecipe(userID uuid.UUID, req *models.RecipeCreateR
 		recipe.Tags = append(recipe.Tags, tag)
 	}
 
+	// Sub-recipes count with their own ingredients
+	ingredients := s.leafIngredients(recipe)
+
 	// Add nutrition
 	if req.Nutrition != nil {
 		recipe.Nutrition = &models.Nutrition{
@@ -97,17 +159,88 @@ func (s *recipeService) CreateRecipe(userID uuid.UUID, req *models.RecipeCreateR
 			Sodium:      req.Nutrition.Sodium,
 			Cholesterol: req.Nutrition.Cholesterol,
 		}
+	} else if len(recipe.Ingredients) > 0 {
+		recipe.Nutrition = calculateNutrition(recipe, ingredients)
 	}
 
-	if err := s.recipeRepo.Create(recipe); err != nil {
+	setDietaryLabels(recipe, ingredients)
+
+	if err := s.recipeRepo.Create(recipe, newRevision(userID, recipe, nil)); err != nil {
 		return nil, err
 	}
 
-	return s.recipeRepo.GetByID(recipe.ID)
+	return s.GetRecipe(recipe.ID)
 }
 
 func (s *recipeService) GetRecipe(id uuid.UUID) (*models.Recipe, error) {
-	return s.recipeRepo.GetByID(id)
+	recipe, err := s.recipeRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.fillForkedFrom(recipe)
+	s.fillSubRecipes(recipe)
+	return recipe, nil
+}
+
+func (s *recipeService) GetScaledRecipe(id uuid.UUID, servings int) (*models.Recipe, error) {
+	recipe, err := s.GetRecipe(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ScaleRecipe(recipe, servings); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// ScaleRecipe scales the ingredient amounts of a loaded recipe from its
+// servings to the given number of servings.
+func (s *recipeService) ScaleRecipe(recipe *models.Recipe, servings int) error {
+	if recipe.Servings == nil || *recipe.Servings <= 0 {
+		return errors.New("recipe has no servings to scale from")
+	}
+
+	factor := float64(servings) / float64(*recipe.Servings)
+	for i := range recipe.Ingredients {
+		ingredient := &recipe.Ingredients[i]
+		if ingredient.Amount == nil {
+			continue
+		}
+
+		unit := ""
+		if ingredient.Unit != nil {
+			unit = *ingredient.Unit
+		}
+
+		amount, scaledUnit := units.Scale(*ingredient.Amount, unit, factor)
+		ingredient.Amount = &amount
+		if ingredient.Unit != nil {
+			ingredient.Unit = &scaledUnit
+		}
+	}
+
+	scaleStepIngredients(recipe.Instructions, factor)
+
+	recipe.Servings = &servings
+	return nil
+}
+
+func (s *recipeService) ConvertRecipe(recipe *models.Recipe, system units.System) {
+	for i := range recipe.Ingredients {
+		ingredient := &recipe.Ingredients[i]
+		if ingredient.Amount == nil || ingredient.Unit == nil {
+			continue
+		}
+
+		amount, unit, ok := units.ConvertIngredient(*ingredient.Amount, *ingredient.Unit, ingredient.Name, system)
+		if ok {
+			ingredient.Amount = &amount
+			ingredient.Unit = &unit
+		}
+	}
+	convertStepIngredients(recipe, system)
 }
 
 func (s *recipeService) GetRecipes(query *models.RecipeQuery) ([]models.Recipe, int64, error) {
@@ -119,6 +252,12 @@ func (s *recipeService) GetMyRecipes(userID uuid.UUID, query *models.RecipeQuery
 }
 
 func (s *recipeService) UpdateRecipe(userID, recipeID uuid.UUID, req *models.RecipeCreateRequest) (*models.Recipe, error) {
+	return s.updateRecipe(userID, recipeID, req, nil)
+}
+
+// updateRecipe saves a recipe and records the result as a new revision.
+// restoredFrom is set when the request is a past revision being restored.
+func (s *recipeService) updateRecipe(userID, recipeID uuid.UUID, req *models.RecipeCreateRequest, restoredFrom *int) (*models.Recipe, error) {
 	// Get existing recipe
 	recipe, err := s.recipeRepo.GetByID(recipeID)
 	if err != nil {
@@ -130,6 +269,9 @@ func (s *recipeService) UpdateRecipe(userID, recipeID uuid.UUID, req *models.Rec
 		return nil, errors.New("unauthorized to update this recipe")
 	}
 
+	// Keep what is about to be overwritten
+	base := baseRevision(recipe)
+
 	// Update fields
 	recipe.Title = req.Title
 	recipe.Description = req.Description
@@ -139,20 +281,33 @@ func (s *recipeService) UpdateRecipe(userID, recipeID uuid.UUID, req *models.Rec
 	recipe.Servings = req.Servings
 	recipe.Difficulty = req.Difficulty
 	recipe.Type = req.Type
+	recipe.SourceURL = req.SourceURL
 
 	if req.IsPublic != nil {
 		recipe.IsPublic = *req.IsPublic
 	}
 
+	if err := applyStatus(recipe, req); err != nil {
+		return nil, err
+	}
+
 	// Update ingredients
 	recipe.Ingredients = nil
+	if len(req.Ingredients) == 0 && len(req.Lines) > 0 {
+		req.Ingredients = s.ParseIngredients(req.Lines)
+	}
+	if err := s.checkSubRecipes(userID, recipeID, req.Ingredients); err != nil {
+		return nil, err
+	}
 	for i, ingredientReq := range req.Ingredients {
 		ingredient := models.Ingredient{
-			Name:       ingredientReq.Name,
-			Amount:     ingredientReq.Amount,
-			Unit:       ingredientReq.Unit,
-			Notes:      ingredientReq.Notes,
-			OrderIndex: i,
+			ID:          uuid.New(),
+			Name:        ingredientReq.Name,
+			Amount:      ingredientReq.Amount,
+			Unit:        ingredientReq.Unit,
+			Notes:       ingredientReq.Notes,
+			SubRecipeID: ingredientReq.SubRecipeID,
+			OrderIndex:  i,
 		}
 		if ingredientReq.OrderIndex != nil {
 			ingredient.OrderIndex = *ingredientReq.OrderIndex
@@ -163,15 +318,23 @@ func (s *recipeService) UpdateRecipe(userID, recipeID uuid.UUID, req *models.Rec
 	// Update instructions
 	recipe.Instructions = nil
 	for _, instructionReq := range req.Instructions {
+		links, err := stepIngredients(instructionReq.Ingredients, recipe.Ingredients)
+		if err != nil {
+			return nil, err
+		}
 		instruction := models.Instruction{
 			Step:         instructionReq.Step,
 			Instruction:  instructionReq.Instruction,
 			ImageURL:     instructionReq.ImageURL,
 			TimerMinutes: instructionReq.TimerMinutes,
+			Ingredients:  links,
 		}
 		recipe.Instructions = append(recipe.Instructions, instruction)
 	}
 
+	// Sub-recipes count with their own ingredients
+	ingredients := s.leafIngredients(recipe)
+
 	// Update nutrition
 	if req.Nutrition != nil {
 		if recipe.Nutrition == nil {
@@ -185,13 +348,21 @@ func (s *recipeService) UpdateRecipe(userID, recipeID uuid.UUID, req *models.Rec
 		recipe.Nutrition.Sugar = req.Nutrition.Sugar
 		recipe.Nutrition.Sodium = req.Nutrition.Sodium
 		recipe.Nutrition.Cholesterol = req.Nutrition.Cholesterol
+		recipe.Nutrition.Calculated = false
+		recipe.Nutrition.UnmatchedIngredients = nil
+	} else {
+		setCalculatedNutrition(recipe, ingredients)
 	}
 
-	if err := s.recipeRepo.Update(recipe); err != nil {
+	setDietaryLabels(recipe, ingredients)
+
+	if err := s.recipeRepo.Update(recipe, base, newRevision(userID, recipe, restoredFrom)); err != nil {
 		return nil, err
 	}
+	s.pruneRevisions(recipe.ID)
+	refreshRecipesUsing(s.recipeRepo, recipe.ID)
 
-	return s.recipeRepo.GetByID(recipe.ID)
+	return s.GetRecipe(recipe.ID)
 }
 
 func (s *recipeService) DeleteRecipe(userID, recipeID uuid.UUID) error {
@@ -206,15 +377,99 @@ func (s *recipeService) DeleteRecipe(userID, recipeID uuid.UUID) error {
 		return errors.New("unauthorized to delete this recipe")
 	}
 
-	return s.recipeRepo.Delete(recipeID)
+	if err := s.recipeRepo.Delete(recipeID); err != nil {
+		return err
+	}
+	refreshRecipesUsing(s.recipeRepo, recipeID)
+
+	if recipe.ForkedFromID != nil {
+		return s.recipeRepo.UpdateForkCount(*recipe.ForkedFromID)
+	}
+	return nil
 }
 
-func (s *recipeService) SearchRecipes(query *models.RecipeQuery) ([]models.Recipe, int64, error) {
+func (s *recipeService) SearchRecipes(query *models.RecipeQuery) ([]models.RecipeSearchResult, int64, error) {
 	return s.recipeRepo.Search(query)
 }
 
-func (s *recipeService) GetFeaturedRecipes(limit int) ([]models.Recipe, error) {
-	return s.recipeRepo.GetFeatured(limit)
+func (s *recipeService) Autocomplete(term string, limit int) (*models.AutocompleteResponse, error) {
+	return s.recipeRepo.Autocomplete(term, limit)
+}
+
+func (s *recipeService) FindByIngredients(userID uuid.UUID, req *models.RecipesByIngredientsRequest) ([]models.RecipeMatch, int64, error) {
+	seen := make(map[string]bool)
+	var available []string
+	for _, name := range req.Ingredients {
+		name = ingredientWords(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			available = append(available, name)
+		}
+	}
+
+	var staples []models.PantryStaple
+	if req.IgnoreStaples {
+		var err error
+		staples, err = s.userRepo.GetStaples(userID)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	candidates, err := s.recipeRepo.FindByIngredients(available, req.MustUseAll, maxIngredientCandidates)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matches := []models.RecipeMatch{}
+	for i := range candidates {
+		recipe := &candidates[i]
+		matched, missing, used := matchRecipe(recipe, available, staples)
+		if len(matched) == 0 {
+			continue
+		}
+		if req.MaxMissing != nil && len(missing) > *req.MaxMissing {
+			continue
+		}
+		if req.MustUseAll && !allTrue(used) {
+			continue
+		}
+
+		recipe.Allergens = food.AllergensOf(ingredientNames(recipe.Ingredients))
+		matches = append(matches, models.RecipeMatch{
+			Recipe:             *recipe,
+			Coverage:           float64(len(matched)) / float64(len(matched)+len(missing)),
+			MatchedIngredients: matched,
+			MissingIngredients: missing,
+		})
+	}
+
+	// Best coverage first, then fewest things to buy, then best rated
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Coverage != matches[j].Coverage {
+			return matches[i].Coverage > matches[j].Coverage
+		}
+		if len(matches[i].MissingIngredients) != len(matches[j].MissingIngredients) {
+			return len(matches[i].MissingIngredients) < len(matches[j].MissingIngredients)
+		}
+		return matches[i].Rating > matches[j].Rating
+	})
+
+	total := int64(len(matches))
+	start := (req.Page - 1) * req.Limit
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + req.Limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	return matches[start:end], total, nil
+}
+
+func (s *recipeService) GetFeaturedRecipes(limit int, query *models.RecipeQuery) ([]models.Recipe, error) {
+	return s.recipeRepo.GetFeatured(limit, query)
 }
 
 func (s *recipeService) FavoriteRecipe(userID, recipeID uuid.UUID) error {
@@ -238,4 +493,59 @@ func (s *recipeService) RateRecipe(userID, recipeID uuid.UUID, req *models.RateR
 	}
 
 	return s.recipeRepo.RateRecipe(rating)
-}
\ No newline at end of file
+}
+
+// BackfillDietaryLabels derives dietary labels and allergens for recipes
+// saved before they were stored.
+func (s *recipeService) BackfillDietaryLabels() error {
+	const batchSize = 100
+	for {
+		recipes, err := s.recipeRepo.GetWithoutDietaryLabels(batchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range recipes {
+			setDietaryLabels(&recipes[i], s.leafIngredients(&recipes[i]))
+			if err := s.recipeRepo.UpdateDietaryLabels(&recipes[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(recipes) < batchSize {
+			return nil
+		}
+	}
+}
+
+// setDietaryLabels derives a recipe's dietary labels and allergens from its
+// ingredients, with sub-recipes expanded.
+func setDietaryLabels(recipe *models.Recipe, ingredients []models.Ingredient) {
+	names := ingredientNames(ingredients)
+	recipe.DietaryLabels, recipe.DietaryReasons = food.DietaryLabels(names)
+	recipe.Allergens = food.AllergensOf(names)
+}
+
+func ingredientNames(ingredients []models.Ingredient) []string {
+	names := make([]string, len(ingredients))
+	for i, ingredient := range ingredients {
+		names[i] = ingredient.Name
+	}
+	return names
+}
+
+func allTrue(values []bool) bool {
+	for _, v := range values {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportRecipe renders a recipe for sharing outside the app. JSON-LD links
+// back to the recipe's page in the app.
+func (s *recipeService) ExportRecipe(recipe *models.Recipe, format exporter.Format) ([]byte, error) {
+	recipeURL := strings.TrimRight(s.appURL, "/") + "/recipe/" + recipe.ID.String()
+	return exporter.Export(recipe, format, recipeURL)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type ShareLinkService interface {
+	CreateRecipeLink(userID, recipeID uuid.UUID, req *models.ShareLinkCreateRequest) (*models.ShareLink, error)
+	CreateCollectionLink(userID, collectionID uuid.UUID, req *models.ShareLinkCreateRequest) (*models.ShareLink, error)
+	GetRecipeLinks(userID, recipeID uuid.UUID) ([]models.ShareLink, error)
+	GetCollectionLinks(userID, collectionID uuid.UUID) ([]models.ShareLink, error)
+	GetLinks(userID uuid.UUID) ([]models.ShareLink, error)
+	RevokeLink(userID, linkID uuid.UUID) error
+	OpenLink(token string) (*models.SharedItem, error)
+}
+
+type shareLinkService struct {
+	shareLinkRepo  repositories.ShareLinkRepository
+	recipeRepo     repositories.RecipeRepository
+	collectionRepo repositories.CollectionRepository
+	recipeService  RecipeService
+	apiURL         string
+}
+
+func NewShareLinkService(shareLinkRepo repositories.ShareLinkRepository, recipeRepo repositories.RecipeRepository, collectionRepo repositories.CollectionRepository, recipeService RecipeService, apiURL string) ShareLinkService {
+	return &shareLinkService{
+		shareLinkRepo:  shareLinkRepo,
+		recipeRepo:     recipeRepo,
+		collectionRepo: collectionRepo,
+		recipeService:  recipeService,
+		apiURL:         apiURL,
+	}
+}
+
+func (s *shareLinkService) CreateRecipeLink(userID, recipeID uuid.UUID, req *models.ShareLinkCreateRequest) (*models.ShareLink, error) {
+	if err := s.ownRecipe(userID, recipeID); err != nil {
+		return nil, err
+	}
+	return s.createLink(userID, models.ShareRecipe, recipeID, req)
+}
+
+func (s *shareLinkService) CreateCollectionLink(userID, collectionID uuid.UUID, req *models.ShareLinkCreateRequest) (*models.ShareLink, error) {
+	if err := s.ownCollection(userID, collectionID); err != nil {
+		return nil, err
+	}
+	return s.createLink(userID, models.ShareCollection, collectionID, req)
+}
+
+// createLink mints a link with a random token. The token is returned with
+// the link but never stored.
+func (s *shareLinkService) createLink(userID uuid.UUID, resourceType string, resourceID uuid.UUID, req *models.ShareLinkCreateRequest) (*models.ShareLink, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	link := &models.ShareLink{
+		UserID:       userID,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Label:        req.Label,
+		TokenHash:    hashShareToken(token),
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if err := s.shareLinkRepo.Create(link); err != nil {
+		return nil, err
+	}
+
+	link.Token = token
+	link.URL = strings.TrimRight(s.apiURL, "/") + "/api/v1/shared/" + token
+	return link, nil
+}
+
+func (s *shareLinkService) GetRecipeLinks(userID, recipeID uuid.UUID) ([]models.ShareLink, error) {
+	if err := s.ownRecipe(userID, recipeID); err != nil {
+		return nil, err
+	}
+	return s.shareLinkRepo.GetActiveByResource(models.ShareRecipe, recipeID)
+}
+
+func (s *shareLinkService) GetCollectionLinks(userID, collectionID uuid.UUID) ([]models.ShareLink, error) {
+	if err := s.ownCollection(userID, collectionID); err != nil {
+		return nil, err
+	}
+	return s.shareLinkRepo.GetActiveByResource(models.ShareCollection, collectionID)
+}
+
+func (s *shareLinkService) GetLinks(userID uuid.UUID) ([]models.ShareLink, error) {
+	return s.shareLinkRepo.GetActiveByUserID(userID)
+}
+
+func (s *shareLinkService) RevokeLink(userID, linkID uuid.UUID) error {
+	link, err := s.shareLinkRepo.GetByID(linkID)
+	if err != nil {
+		return errors.New("share link not found")
+	}
+
+	if link.UserID != userID {
+		return errors.New("unauthorized to revoke this share link")
+	}
+
+	return s.shareLinkRepo.Revoke(link.ID)
+}
+
+// OpenLink returns the item a link was made for and counts the view.
+// Revoked and expired links, and links to deleted items, open nothing.
+func (s *shareLinkService) OpenLink(token string) (*models.SharedItem, error) {
+	link, err := s.shareLinkRepo.GetByTokenHash(hashShareToken(token))
+	if err != nil || !link.IsActive(time.Now()) {
+		return nil, errors.New("share link not found")
+	}
+
+	item := &models.SharedItem{Type: link.ResourceType}
+	switch link.ResourceType {
+	case models.ShareRecipe:
+		recipe, err := s.recipeService.GetRecipe(link.ResourceID)
+		if err != nil {
+			return nil, errors.New("share link not found")
+		}
+		item.Recipe = recipe
+	case models.ShareCollection:
+		collection, err := s.collectionRepo.GetByID(link.ResourceID)
+		if err != nil {
+			return nil, errors.New("share link not found")
+		}
+
+		// The link shares the owner's recipes, not other people's private
+		// ones that were added to the collection
+		recipes := collection.Recipes[:0]
+		for _, recipe := range collection.Recipes {
+			if recipe.UserID == collection.UserID || recipe.IsListed() {
+				recipes = append(recipes, recipe)
+			}
+		}
+		collection.Recipes = recipes
+		item.Collection = collection
+	default:
+		return nil, errors.New("share link not found")
+	}
+
+	if err := s.shareLinkRepo.RecordView(link.ID); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *shareLinkService) ownRecipe(userID, recipeID uuid.UUID) error {
+	recipe, err := s.recipeRepo.GetByID(recipeID)
+	if err != nil {
+		return errors.New("recipe not found")
+	}
+	if recipe.UserID != userID {
+		return errors.New("unauthorized to share this recipe")
+	}
+	return nil
+}
+
+func (s *shareLinkService) ownCollection(userID, collectionID uuid.UUID) error {
+	collection, err := s.collectionRepo.GetByID(collectionID)
+	if err != nil {
+		return errors.New("collection not found")
+	}
+	if collection.UserID != userID {
+		return errors.New("unauthorized to share this collection")
+	}
+	return nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
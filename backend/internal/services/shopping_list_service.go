@@ -4,28 +4,35 @@ import (
 	"errors"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/repositories"
+	"yummio-backend/internal/units"
 
 	"github.com/google/uuid"
 )
 
 type ShoppingListService interface {
 	CreateShoppingList(userID uuid.UUID, req *models.ShoppingListCreateRequest) (*models.ShoppingList, error)
+	CreateFromRecipes(userID uuid.UUID, req *models.ShoppingListFromRecipesRequest) (*models.ShoppingList, error)
 	GetShoppingList(userID, listID uuid.UUID) (*models.ShoppingList, error)
 	GetShoppingLists(userID uuid.UUID) ([]models.ShoppingList, error)
+	ConvertShoppingList(list *models.ShoppingList, system units.System)
 	UpdateShoppingList(userID, listID uuid.UUID, req *models.ShoppingListUpdateRequest) (*models.ShoppingList, error)
 	DeleteShoppingList(userID, listID uuid.UUID) error
 	AddItem(userID, listID uuid.UUID, req *models.ShoppingListItemCreateRequest) (*models.ShoppingListItem, error)
 	UpdateItem(userID, listID, itemID uuid.UUID, req *models.ShoppingListItemUpdateRequest) (*models.ShoppingListItem, error)
 	DeleteItem(userID, listID, itemID uuid.UUID) error
+	AddRecipe(userID, listID uuid.UUID, req *models.RecipeServingsRequest) (*models.ShoppingList, error)
+	RemoveRecipe(userID, listID, recipeID uuid.UUID) (*models.ShoppingList, error)
 }
 
 type shoppingListService struct {
 	shoppingListRepo repositories.ShoppingListRepository
+	recipeRepo       repositories.RecipeRepository
 }
 
-func NewShoppingListService(shoppingListRepo repositories.ShoppingListRepository) ShoppingListService {
+func NewShoppingListService(shoppingListRepo repositories.ShoppingListRepository, recipeRepo repositories.RecipeRepository) ShoppingListService {
 	return &shoppingListService{
 		shoppingListRepo: shoppingListRepo,
+		recipeRepo:       recipeRepo,
 	}
 }
 
@@ -57,6 +64,30 @@ func (s *shoppingListService) CreateShoppingList(userID uuid.UUID, req *models.S
 	return s.shoppingListRepo.GetByID(list.ID)
 }
 
+func (s *shoppingListService) CreateFromRecipes(userID uuid.UUID, req *models.ShoppingListFromRecipesRequest) (*models.ShoppingList, error) {
+	list := &models.ShoppingList{
+		UserID: userID,
+		Name:   req.Name,
+	}
+
+	for _, recipeReq := range req.Recipes {
+		recipe, err := s.getAccessibleRecipe(userID, recipeReq.RecipeID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range recipeItems(withSubRecipes(s.recipeRepo, recipe), recipeReq.Servings) {
+			list.Items, _ = mergeItem(list.Items, item)
+		}
+	}
+
+	if err := s.shoppingListRepo.Create(list); err != nil {
+		return nil, err
+	}
+
+	return s.shoppingListRepo.GetByID(list.ID)
+}
+
 func (s *shoppingListService) GetShoppingList(userID, listID uuid.UUID) (*models.ShoppingList, error) {
 	list, err := s.shoppingListRepo.GetByID(listID)
 	if err != nil {
@@ -75,6 +106,21 @@ func (s *shoppingListService) GetShoppingLists(userID uuid.UUID) ([]models.Shopp
 	return s.shoppingListRepo.GetByUserID(userID)
 }
 
+func (s *shoppingListService) ConvertShoppingList(list *models.ShoppingList, system units.System) {
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.Amount == nil || item.Unit == nil {
+			continue
+		}
+
+		amount, unit, ok := units.ConvertIngredient(*item.Amount, *item.Unit, item.Name, system)
+		if ok {
+			item.Amount = &amount
+			item.Unit = &unit
+		}
+	}
+}
+
 func (s *shoppingListService) UpdateShoppingList(userID, listID uuid.UUID, req *models.ShoppingListUpdateRequest) (*models.ShoppingList, error) {
 	list, err := s.shoppingListRepo.GetByID(listID)
 	if err != nil {
@@ -214,4 +260,103 @@ func (s *shoppingListService) DeleteItem(userID, listID, itemID uuid.UUID) error
 	}
 
 	return s.shoppingListRepo.DeleteItem(itemID)
+}
+
+func (s *shoppingListService) AddRecipe(userID, listID uuid.UUID, req *models.RecipeServingsRequest) (*models.ShoppingList, error) {
+	list, err := s.shoppingListRepo.GetByID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check ownership
+	if list.UserID != userID {
+		return nil, errors.New("unauthorized to modify this shopping list")
+	}
+
+	recipe, err := s.getAccessibleRecipe(userID, req.RecipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge ingredients into matching uncompleted items
+	items := list.Items
+	touched := make(map[int]bool)
+	for _, item := range recipeItems(withSubRecipes(s.recipeRepo, recipe), req.Servings) {
+		var index int
+		items, index = mergeItem(items, item)
+		touched[index] = true
+	}
+
+	var changed []models.ShoppingListItem
+	for i := range items {
+		if touched[i] {
+			items[i].ShoppingListID = listID
+			changed = append(changed, items[i])
+		}
+	}
+
+	if err := s.shoppingListRepo.SaveItems(changed, nil); err != nil {
+		return nil, err
+	}
+
+	return s.shoppingListRepo.GetByID(listID)
+}
+
+func (s *shoppingListService) RemoveRecipe(userID, listID, recipeID uuid.UUID) (*models.ShoppingList, error) {
+	list, err := s.shoppingListRepo.GetByID(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check ownership
+	if list.UserID != userID {
+		return nil, errors.New("unauthorized to modify this shopping list")
+	}
+
+	// Add up what the other recipes contributed to every item the recipe
+	// added to, rather than subtracting, which rounding would throw off
+	var changed []models.ShoppingListItem
+	var deleteIDs []uuid.UUID
+	for _, item := range list.Items {
+		var remaining []models.ShoppingListItemSource
+		for _, source := range item.Sources {
+			if source.RecipeID != recipeID {
+				remaining = append(remaining, source)
+			}
+		}
+		if len(remaining) == len(item.Sources) {
+			continue
+		}
+
+		if len(remaining) == 0 {
+			deleteIDs = append(deleteIDs, item.ID)
+			continue
+		}
+		if amount, unit, ok := sourcesAmount(item.Name, remaining); ok {
+			item.Amount = amount
+			item.Unit = unit
+		}
+		item.Sources = remaining
+		changed = append(changed, item)
+	}
+
+	if err := s.shoppingListRepo.SaveItems(changed, deleteIDs); err != nil {
+		return nil, err
+	}
+
+	return s.shoppingListRepo.GetByID(listID)
+}
+
+func (s *shoppingListService) getAccessibleRecipe(userID, recipeID uuid.UUID) (*models.Recipe, error) {
+	recipe, err := s.recipeRepo.GetByID(recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check access permissions
+	if recipe.UserID != userID && !recipe.IsListed() {
+		return nil, errors.New("unauthorized to access this recipe")
+	}
+
+	return recipe, nil
 }
\ No newline at end of file
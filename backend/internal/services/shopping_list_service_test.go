@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type fakeShoppingListRepository struct {
+	repositories.ShoppingListRepository
+	list *models.ShoppingList
+}
+
+func (r *fakeShoppingListRepository) GetByID(id uuid.UUID) (*models.ShoppingList, error) {
+	copied := *r.list
+	copied.Items = append([]models.ShoppingListItem(nil), r.list.Items...)
+	return &copied, nil
+}
+
+func (r *fakeShoppingListRepository) SaveItems(items []models.ShoppingListItem, deleteIDs []uuid.UUID) error {
+	for _, item := range items {
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+			r.list.Items = append(r.list.Items, item)
+			continue
+		}
+		for i := range r.list.Items {
+			if r.list.Items[i].ID == item.ID {
+				r.list.Items[i] = item
+			}
+		}
+	}
+
+	var kept []models.ShoppingListItem
+	for _, item := range r.list.Items {
+		deleted := false
+		for _, id := range deleteIDs {
+			deleted = deleted || item.ID == id
+		}
+		if !deleted {
+			kept = append(kept, item)
+		}
+	}
+	r.list.Items = kept
+	return nil
+}
+
+func TestRemoveRecipeKeepsManualItems(t *testing.T) {
+	owner := uuid.New()
+	amount := func(f float64) *float64 { return &f }
+	eggs := models.Recipe{
+		ID:          uuid.New(),
+		UserID:      owner,
+		Title:       "Omelette",
+		Ingredients: []models.Ingredient{{Name: "eggs", Amount: amount(3)}},
+	}
+	list := &models.ShoppingList{
+		ID:     uuid.New(),
+		UserID: owner,
+		Items:  []models.ShoppingListItem{{ID: uuid.New(), Name: "eggs", Amount: amount(2)}},
+	}
+
+	lists := &fakeShoppingListRepository{list: list}
+	recipes := &fakeRecipeRepository{recipes: map[uuid.UUID]*models.Recipe{eggs.ID: &eggs}}
+	service := NewShoppingListService(lists, recipes)
+
+	added, err := service.AddRecipe(owner, list.ID, &models.RecipeServingsRequest{RecipeID: eggs.ID})
+	if err != nil {
+		t.Fatalf("AddRecipe() error = %v", err)
+	}
+	if len(added.Items) != 2 || *added.Items[0].Amount != 2 || *added.Items[1].Amount != 3 {
+		t.Fatalf("items after adding the recipe = %+v, want the manual 2 eggs and the recipe's 3", added.Items)
+	}
+
+	removed, err := service.RemoveRecipe(owner, list.ID, eggs.ID)
+	if err != nil {
+		t.Fatalf("RemoveRecipe() error = %v", err)
+	}
+	if len(removed.Items) != 1 || removed.Items[0].Amount == nil || *removed.Items[0].Amount != 2 {
+		t.Errorf("items after removing the recipe = %+v, want 2 eggs", removed.Items)
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+	"yummio-backend/internal/units"
+
+	"github.com/google/uuid"
+)
+
+// maxSubRecipeDepth bounds how deeply sub-recipes nest, counting the
+// recipe using them as the first level.
+const maxSubRecipeDepth = 5
+
+// subRecipeLoader loads the sub-recipes of a recipe, each only once.
+// ownerID is the user whose recipe uses them.
+type subRecipeLoader struct {
+	recipeRepo repositories.RecipeRepository
+	ownerID    uuid.UUID
+	recipes    map[uuid.UUID]*models.Recipe
+}
+
+func newSubRecipeLoader(recipeRepo repositories.RecipeRepository, ownerID uuid.UUID) *subRecipeLoader {
+	return &subRecipeLoader{
+		recipeRepo: recipeRepo,
+		ownerID:    ownerID,
+		recipes:    make(map[uuid.UUID]*models.Recipe),
+	}
+}
+
+// fetch loads a recipe, or returns nil if it doesn't exist.
+func (l *subRecipeLoader) fetch(id uuid.UUID) *models.Recipe {
+	if recipe, ok := l.recipes[id]; ok {
+		return recipe
+	}
+
+	recipe, err := l.recipeRepo.GetByID(id)
+	if err != nil {
+		recipe = nil
+	}
+	l.recipes[id] = recipe
+	return recipe
+}
+
+// get loads a sub-recipe the owner may use: one of their own or a listed
+// one. It returns nil otherwise.
+func (l *subRecipeLoader) get(id uuid.UUID) *models.Recipe {
+	recipe := l.fetch(id)
+	if recipe == nil || (recipe.UserID != l.ownerID && !recipe.IsListed()) {
+		return nil
+	}
+	return recipe
+}
+
+// depth returns how many levels of sub-recipes a recipe has, counting
+// itself, and whether target is one of them. Recipes the owner can't see
+// are followed too, so no cycle goes unnoticed.
+func (l *subRecipeLoader) depth(recipe *models.Recipe, target uuid.UUID, path []uuid.UUID) (int, bool) {
+	path = append(path[:len(path):len(path)], recipe.ID)
+	deepest := 1
+	for _, ingredient := range recipe.Ingredients {
+		if ingredient.SubRecipeID == nil {
+			continue
+		}
+		if *ingredient.SubRecipeID == target {
+			return 0, true
+		}
+		if containsID(path, *ingredient.SubRecipeID) || len(path) > maxSubRecipeDepth {
+			continue
+		}
+
+		sub := l.fetch(*ingredient.SubRecipeID)
+		if sub == nil {
+			continue
+		}
+		levels, found := l.depth(sub, target, path)
+		if found {
+			return 0, true
+		}
+		if levels+1 > deepest {
+			deepest = levels + 1
+		}
+	}
+	return deepest, false
+}
+
+// expand replaces sub-recipes among a recipe's ingredients with their own
+// ingredients, scaled to the amount used, down to plain ingredients.
+// Sub-recipes that are gone or have become private stay as they are.
+func (l *subRecipeLoader) expand(recipe *models.Recipe) []models.Ingredient {
+	return l.expandIngredients(recipe.Ingredients, 1, []uuid.UUID{recipe.ID})
+}
+
+func (l *subRecipeLoader) expandIngredients(ingredients []models.Ingredient, factor float64, path []uuid.UUID) []models.Ingredient {
+	var expanded []models.Ingredient
+	for _, ingredient := range ingredients {
+		var sub *models.Recipe
+		if ingredient.SubRecipeID != nil && len(path) < maxSubRecipeDepth && !containsID(path, *ingredient.SubRecipeID) {
+			sub = l.get(*ingredient.SubRecipeID)
+		}
+
+		if sub == nil {
+			expanded = append(expanded, scaleIngredient(ingredient, factor))
+			continue
+		}
+
+		subPath := append(path[:len(path):len(path)], sub.ID)
+		expanded = append(expanded, l.expandIngredients(sub.Ingredients, factor*subRecipeBatches(ingredient, sub), subPath)...)
+	}
+	return expanded
+}
+
+// subRecipeBatches is how many times a sub-recipe is made for the
+// ingredient using it. Amounts in servings are divided by the servings the
+// sub-recipe makes. Amounts in any other unit, such as "2 cups" of a sauce,
+// can't be related to the recipe, so one batch is made.
+func subRecipeBatches(ingredient models.Ingredient, sub *models.Recipe) float64 {
+	if ingredient.Amount == nil || *ingredient.Amount <= 0 {
+		return 1
+	}
+
+	unit := ""
+	if ingredient.Unit != nil {
+		unit = strings.ToLower(strings.TrimSpace(*ingredient.Unit))
+	}
+	switch unit {
+	case "":
+		return *ingredient.Amount
+	case "serving", "servings":
+		if sub.Servings != nil && *sub.Servings > 0 {
+			return *ingredient.Amount / float64(*sub.Servings)
+		}
+		return 1
+	default:
+		return 1
+	}
+}
+
+func scaleIngredient(ingredient models.Ingredient, factor float64) models.Ingredient {
+	if factor == 1 || ingredient.Amount == nil {
+		return ingredient
+	}
+
+	unit := ""
+	if ingredient.Unit != nil {
+		unit = *ingredient.Unit
+	}
+	amount, scaledUnit := units.Scale(*ingredient.Amount, unit, factor)
+	ingredient.Amount = &amount
+	if ingredient.Unit != nil {
+		ingredient.Unit = &scaledUnit
+	}
+	return ingredient
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSubRecipes makes sure the sub-recipes used by a recipe exist, may be
+// used by the user and don't lead back to the recipe. recipeID is uuid.Nil
+// for a new recipe. Unnamed sub-recipe ingredients get the sub-recipe's
+// title.
+func (s *recipeService) checkSubRecipes(userID, recipeID uuid.UUID, ingredients []models.IngredientCreateRequest) error {
+	loader := newSubRecipeLoader(s.recipeRepo, userID)
+	for i := range ingredients {
+		ingredient := &ingredients[i]
+		if ingredient.SubRecipeID == nil {
+			continue
+		}
+		if *ingredient.SubRecipeID == recipeID {
+			return errors.New("a recipe can't be an ingredient of itself")
+		}
+
+		sub := loader.get(*ingredient.SubRecipeID)
+		if sub == nil {
+			return errors.New("sub-recipe not found")
+		}
+
+		levels, cyclic := loader.depth(sub, recipeID, []uuid.UUID{recipeID})
+		if cyclic {
+			return errors.New("sub-recipe already uses this recipe")
+		}
+		if levels >= maxSubRecipeDepth {
+			return errors.New("sub-recipes are nested too deeply")
+		}
+
+		if strings.TrimSpace(ingredient.Name) == "" {
+			ingredient.Name = sub.Title
+		}
+	}
+	return nil
+}
+
+// dropUnusableSubRecipes unlinks sub-recipes the user can't use, keeping
+// them as plain ingredients. Copies of other people's recipes and restored
+// revisions may point at recipes that are private or gone.
+func (s *recipeService) dropUnusableSubRecipes(userID uuid.UUID, ingredients []models.IngredientCreateRequest) {
+	loader := newSubRecipeLoader(s.recipeRepo, userID)
+	for i := range ingredients {
+		if id := ingredients[i].SubRecipeID; id != nil && loader.get(*id) == nil {
+			ingredients[i].SubRecipeID = nil
+		}
+	}
+}
+
+// fillSubRecipes shows the recipes used as ingredients.
+func (s *recipeService) fillSubRecipes(recipe *models.Recipe) {
+	loader := newSubRecipeLoader(s.recipeRepo, recipe.UserID)
+	for i := range recipe.Ingredients {
+		ingredient := &recipe.Ingredients[i]
+		if ingredient.SubRecipeID == nil {
+			continue
+		}
+
+		sub := loader.get(*ingredient.SubRecipeID)
+		if sub == nil {
+			continue
+		}
+		ingredient.SubRecipe = &models.RecipeComponent{
+			ID:          sub.ID,
+			Title:       sub.Title,
+			ImageURL:    sub.ImageURL,
+			Servings:    sub.Servings,
+			Ingredients: sub.Ingredients,
+		}
+	}
+}
+
+// leafIngredients returns a recipe's ingredients with its sub-recipes
+// expanded, as needed for nutrition, dietary labels and allergens.
+func (s *recipeService) leafIngredients(recipe *models.Recipe) []models.Ingredient {
+	return newSubRecipeLoader(s.recipeRepo, recipe.UserID).expand(recipe)
+}
+
+// refreshRecipesUsing recomputes the dietary labels, allergens and
+// calculated nutrition of the recipes using a sub-recipe that was changed, deleted or
+// restored. The recipe has been saved by then, so failures are only logged.
+func refreshRecipesUsing(recipeRepo repositories.RecipeRepository, subRecipeID uuid.UUID) {
+	users, err := recipeRepo.GetUsingSubRecipe(subRecipeID)
+	if err != nil {
+		log.Printf("Failed to find recipes using recipe %s: %v", subRecipeID, err)
+		return
+	}
+	refreshRecipes(recipeRepo, subRecipeID, users)
+}
+
+// refreshRecipes recomputes what the given recipes derive from their
+// ingredients after their sub-recipe changed, then does the same for the
+// recipes using them in turn.
+func refreshRecipes(recipeRepo repositories.RecipeRepository, changed uuid.UUID, ids []uuid.UUID) {
+	seen := map[uuid.UUID]bool{changed: true}
+	for len(ids) > 0 {
+		id := ids[0]
+		ids = ids[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		recipe, err := recipeRepo.GetByID(id)
+		if err != nil {
+			continue
+		}
+		ingredients := newSubRecipeLoader(recipeRepo, recipe.UserID).expand(recipe)
+		setCalculatedNutrition(recipe, ingredients)
+		setDietaryLabels(recipe, ingredients)
+		if err := recipeRepo.UpdateDerived(recipe); err != nil {
+			log.Printf("Failed to refresh recipe %s: %v", id, err)
+			continue
+		}
+
+		users, err := recipeRepo.GetUsingSubRecipe(id)
+		if err != nil {
+			log.Printf("Failed to find recipes using recipe %s: %v", id, err)
+			continue
+		}
+		ids = append(ids, users...)
+	}
+}
+
+// withSubRecipes returns a copy of a recipe whose sub-recipes are replaced
+// by their ingredients, for shopping lists.
+func withSubRecipes(recipeRepo repositories.RecipeRepository, recipe *models.Recipe) *models.Recipe {
+	expanded := *recipe
+	expanded.Ingredients = newSubRecipeLoader(recipeRepo, recipe.UserID).expand(recipe)
+	return &expanded
+}
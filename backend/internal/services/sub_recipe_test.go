@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type fakeRecipeRepository struct {
+	repositories.RecipeRepository
+	recipes map[uuid.UUID]*models.Recipe
+	updated []uuid.UUID
+}
+
+func (r *fakeRecipeRepository) GetByID(id uuid.UUID) (*models.Recipe, error) {
+	recipe, ok := r.recipes[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *recipe
+	return &copied, nil
+}
+
+func (r *fakeRecipeRepository) GetUsingSubRecipe(subRecipeID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, recipe := range r.recipes {
+		for _, ingredient := range recipe.Ingredients {
+			if ingredient.SubRecipeID != nil && *ingredient.SubRecipeID == subRecipeID {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeRecipeRepository) Create(recipe *models.Recipe, revision *models.RecipeRevision) error {
+	recipe.ID = uuid.New()
+	copied := *recipe
+	r.recipes[recipe.ID] = &copied
+	return nil
+}
+
+func (r *fakeRecipeRepository) UpdateDerived(recipe *models.Recipe) error {
+	stored := r.recipes[recipe.ID]
+	stored.DietaryLabels, stored.DietaryReasons, stored.Allergens = recipe.DietaryLabels, recipe.DietaryReasons, recipe.Allergens
+	stored.Nutrition = recipe.Nutrition
+	r.updated = append(r.updated, recipe.ID)
+	return nil
+}
+
+func TestRefreshRecipesUsing(t *testing.T) {
+	owner := uuid.New()
+	calories, servings := 500, 4
+	recipe := func(title string, nutrition *models.Nutrition, ingredients ...models.Ingredient) *models.Recipe {
+		return &models.Recipe{
+			ID:            uuid.New(),
+			UserID:        owner,
+			Title:         title,
+			Servings:      &servings,
+			Status:        models.RecipeStatusPublished,
+			IsPublic:      true,
+			DietaryLabels: []string{"vegan", "vegetarian", "gluten-free", "keto"},
+			Ingredients:   ingredients,
+			Nutrition:     nutrition,
+		}
+	}
+	uses := func(sub *models.Recipe) models.Ingredient {
+		return models.Ingredient{Name: sub.Title, SubRecipeID: &sub.ID}
+	}
+
+	spices := recipe("Spice Mix", nil, models.Ingredient{Name: "paprika"}, models.Ingredient{Name: "bacon"})
+	chili := recipe("Chili", &models.Nutrition{Calculated: true}, models.Ingredient{Name: "tomatoes"}, uses(spices))
+	bowl := recipe("Chili Bowl", &models.Nutrition{Calories: &calories}, models.Ingredient{Name: "avocado"}, uses(chili))
+	salad := recipe("Salad", nil, models.Ingredient{Name: "lettuce"})
+	// A cycle must not loop forever
+	spices.Ingredients = append(spices.Ingredients, uses(bowl))
+
+	repo := &fakeRecipeRepository{recipes: map[uuid.UUID]*models.Recipe{
+		spices.ID: spices,
+		chili.ID:  chili,
+		bowl.ID:   bowl,
+		salad.ID:  salad,
+	}}
+
+	refreshRecipesUsing(repo, spices.ID)
+
+	if len(repo.updated) != 2 {
+		t.Fatalf("updated %d recipes, want chili and its bowl", len(repo.updated))
+	}
+	for _, r := range []*models.Recipe{chili, bowl} {
+		for _, label := range r.DietaryLabels {
+			if label == "vegan" || label == "vegetarian" {
+				t.Errorf("%s is still labelled %s", r.Title, label)
+			}
+		}
+	}
+	if len(salad.DietaryLabels) != 4 {
+		t.Errorf("salad labels = %v, want them untouched", salad.DietaryLabels)
+	}
+
+	if chili.Nutrition == nil || !chili.Nutrition.Calculated || chili.Nutrition.Calories == nil {
+		t.Errorf("chili nutrition = %+v, want it recalculated", chili.Nutrition)
+	}
+	if bowl.Nutrition.Calculated || *bowl.Nutrition.Calories != calories {
+		t.Errorf("bowl nutrition = %+v, want the author's values kept", bowl.Nutrition)
+	}
+}
+
+func TestSubRecipeAllergens(t *testing.T) {
+	owner := uuid.New()
+	dough := &models.Recipe{
+		ID:          uuid.New(),
+		UserID:      owner,
+		Title:       "Pizza Dough",
+		Status:      models.RecipeStatusPublished,
+		Ingredients: []models.Ingredient{{Name: "flour"}, {Name: "water"}, {Name: "yeast"}},
+	}
+	repo := &fakeRecipeRepository{recipes: map[uuid.UUID]*models.Recipe{dough.ID: dough}}
+	service := NewRecipeService(repo, nil, nil, "")
+
+	pizza, err := service.CreateRecipe(owner, &models.RecipeCreateRequest{
+		Title: "Marinara Pizza",
+		Ingredients: []models.IngredientCreateRequest{
+			{Name: "tomato sauce"},
+			{Name: "Pizza Dough", SubRecipeID: &dough.ID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe() error = %v", err)
+	}
+	if got := repo.recipes[pizza.ID].Allergens; !reflect.DeepEqual(got, []string{"gluten"}) {
+		t.Errorf("stored pizza allergens = %q, want gluten from its dough", got)
+	}
+
+	// A gluten-free dough clears the pizza's allergens too
+	dough.Ingredients = []models.Ingredient{{Name: "rice flour"}, {Name: "water"}}
+	refreshRecipesUsing(repo, dough.ID)
+	if got := repo.recipes[pizza.ID].Allergens; len(got) != 0 {
+		t.Errorf("stored pizza allergens after changing the dough = %q, want none", got)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+	"yummio-backend/internal/models"
+	"yummio-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type TrashService interface {
+	GetTrash(userID uuid.UUID) ([]models.TrashItem, error)
+	RestoreItem(userID uuid.UUID, itemType string, id uuid.UUID) error
+	PurgeItem(userID uuid.UUID, itemType string, id uuid.UUID) error
+	EmptyTrash(userID uuid.UUID) (int, error)
+	StartRetention()
+}
+
+// retentionInterval is how often trash past its retention period is
+// purged.
+const retentionInterval = time.Hour
+
+type trashService struct {
+	trashRepo  repositories.TrashRepository
+	recipeRepo repositories.RecipeRepository
+	retention  time.Duration
+}
+
+func NewTrashService(trashRepo repositories.TrashRepository, recipeRepo repositories.RecipeRepository, retention time.Duration) TrashService {
+	return &trashService{
+		trashRepo:  trashRepo,
+		recipeRepo: recipeRepo,
+		retention:  retention,
+	}
+}
+
+func (s *trashService) GetTrash(userID uuid.UUID) ([]models.TrashItem, error) {
+	items, err := s.trashRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.retention > 0 {
+		for i := range items {
+			purgeAt := items[i].DeletedAt.Add(s.retention)
+			items[i].PurgeAt = &purgeAt
+		}
+	}
+	return items, nil
+}
+
+func (s *trashService) RestoreItem(userID uuid.UUID, itemType string, id uuid.UUID) error {
+	if err := s.ownItem(userID, itemType, id, "unauthorized to restore this item"); err != nil {
+		return err
+	}
+
+	if err := s.trashRepo.Restore(itemType, id); err != nil {
+		return err
+	}
+
+	if itemType == models.TrashRecipe {
+		refreshRecipesUsing(s.recipeRepo, id)
+
+		// Deleted forks don't count towards the original's forks
+		recipe, err := s.recipeRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if recipe.ForkedFromID != nil {
+			return s.recipeRepo.UpdateForkCount(*recipe.ForkedFromID)
+		}
+	}
+	return nil
+}
+
+func (s *trashService) PurgeItem(userID uuid.UUID, itemType string, id uuid.UUID) error {
+	if err := s.ownItem(userID, itemType, id, "unauthorized to purge this item"); err != nil {
+		return err
+	}
+	return s.purge(itemType, id)
+}
+
+// EmptyTrash purges everything in the user's trash and returns how many
+// items there were.
+func (s *trashService) EmptyTrash(userID uuid.UUID) (int, error) {
+	items, err := s.trashRepo.GetByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, item := range items {
+		if err := s.purge(item.Type, item.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(items), nil
+}
+
+// purge deletes a trashed item for good. Recipes using a purged recipe
+// keep it as a plain ingredient, so what they derive from their
+// ingredients is recomputed.
+func (s *trashService) purge(itemType string, id uuid.UUID) error {
+	if itemType != models.TrashRecipe {
+		return s.trashRepo.Purge(itemType, id)
+	}
+
+	// The links are gone once it is purged
+	users, err := s.recipeRepo.GetUsingSubRecipe(id)
+	if err != nil {
+		return err
+	}
+	if err := s.trashRepo.Purge(itemType, id); err != nil {
+		return err
+	}
+	refreshRecipes(s.recipeRepo, id, users)
+	return nil
+}
+
+// ownItem checks that a trashed item exists and belongs to the user.
+func (s *trashService) ownItem(userID uuid.UUID, itemType string, id uuid.UUID, unauthorized string) error {
+	switch itemType {
+	case models.TrashRecipe, models.TrashCollection, models.TrashShoppingList:
+	default:
+		return errors.New("unknown trash type")
+	}
+
+	owner, err := s.trashRepo.GetOwner(itemType, id)
+	if err != nil {
+		return errors.New("item not found in trash")
+	}
+	if owner != userID {
+		return errors.New(unauthorized)
+	}
+	return nil
+}
+
+// StartRetention purges trash older than the retention period in the
+// background. Nothing is purged when there is no retention period.
+func (s *trashService) StartRetention() {
+	if s.retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(retentionInterval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeExpired()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *trashService) purgeExpired() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Purging expired trash panicked: %v", r)
+		}
+	}()
+
+	count, err := s.trashRepo.PurgeDeletedBefore(time.Now().Add(-s.retention))
+	if err != nil {
+		log.Printf("Failed to purge expired trash: %v", err)
+	}
+	if count > 0 {
+		log.Printf("Purged %d items from the trash", count)
+	}
+}
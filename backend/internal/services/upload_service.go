@@ -18,6 +18,7 @@ import (
 
 type UploadService interface {
 	UploadImage(file multipart.File, header *multipart.FileHeader) (string, error)
+	UploadImageData(data []byte, filename string) (string, error)
 }
 
 type uploadService struct {
@@ -49,8 +50,25 @@ func NewUploadService(cfg *config.Config) UploadService {
 }
 
 func (s *uploadService) UploadImage(file multipart.File, header *multipart.FileHeader) (string, error) {
+	// Validate file size
+	if header.Size > s.config.Upload.MaxSize {
+		return "", fmt.Errorf("file too large: %d bytes", header.Size)
+	}
+
+	// Read file content
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(file); err != nil {
+		return "", err
+	}
+
+	return s.UploadImageData(buf.Bytes(), header.Filename)
+}
+
+// UploadImageData uploads an image that is already in memory, such as one
+// taken from an imported recipe archive.
+func (s *uploadService) UploadImageData(data []byte, name string) (string, error) {
 	// Validate file type
-	ext := strings.ToLower(filepath.Ext(header.Filename))
+	ext := strings.ToLower(filepath.Ext(name))
 	ext = strings.TrimPrefix(ext, ".")
 	
 	validType := false
@@ -66,8 +84,8 @@ func (s *uploadService) UploadImage(file multipart.File, header *multipart.FileH
 	}
 
 	// Validate file size
-	if header.Size > s.config.Upload.MaxSize {
-		return "", fmt.Errorf("file too large: %d bytes", header.Size)
+	if int64(len(data)) > s.config.Upload.MaxSize {
+		return "", fmt.Errorf("file too large: %d bytes", len(data))
 	}
 
 	// Generate unique filename
@@ -77,17 +95,11 @@ func (s *uploadService) UploadImage(file multipart.File, header *multipart.FileH
 		ext,
 	)
 
-	// Read file content
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(file); err != nil {
-		return "", err
-	}
-
 	// Upload to S3
 	_, err := s.s3Client.PutObject(&s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(filename),
-		Body:        bytes.NewReader(buf.Bytes()),
+		Body:        bytes.NewReader(data),
 		ContentType: aws.String(getContentType(ext)),
 		ACL:         aws.String("public-read"),
 	})
@@ -119,4 +131,8 @@ type mockUploadService struct{}
 func (s *mockUploadService) UploadImage(file multipart.File, header *multipart.FileHeader) (string, error) {
 	// Return a mock URL for development
 	return fmt.Sprintf("https://picsum.photos/800/600?random=%s", uuid.New().String()), nil
+}
+
+func (s *mockUploadService) UploadImageData(data []byte, name string) (string, error) {
+	return fmt.Sprintf("https://picsum.photos/800/600?random=%s", uuid.New().String()), nil
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"strings"
 	"yummio-backend/internal/models"
 	"yummio-backend/internal/repositories"
 
@@ -14,6 +15,9 @@ type UserService interface {
 	UpdateProfile(userID uuid.UUID, req *models.UserUpdateRequest) (*models.UserResponse, error)
 	ChangePassword(userID uuid.UUID, req *models.ChangePasswordRequest) error
 	DeleteProfile(userID uuid.UUID) error
+	GetStaples(userID uuid.UUID) ([]models.PantryStaple, error)
+	AddStaple(userID uuid.UUID, req *models.PantryStapleCreateRequest) (*models.PantryStaple, error)
+	DeleteStaple(userID, stapleID uuid.UUID) error
 }
 
 type userService struct {
@@ -82,4 +86,25 @@ func (s *userService) ChangePassword(userID uuid.UUID, req *models.ChangePasswor
 
 func (s *userService) DeleteProfile(userID uuid.UUID) error {
 	return s.userRepo.Delete(userID)
+}
+
+func (s *userService) GetStaples(userID uuid.UUID) ([]models.PantryStaple, error) {
+	return s.userRepo.GetStaples(userID)
+}
+
+func (s *userService) AddStaple(userID uuid.UUID, req *models.PantryStapleCreateRequest) (*models.PantryStaple, error) {
+	staple := &models.PantryStaple{
+		UserID: userID,
+		Name:   strings.ToLower(strings.TrimSpace(req.Name)),
+	}
+
+	if err := s.userRepo.AddStaple(staple); err != nil {
+		return nil, err
+	}
+
+	return staple, nil
+}
+
+func (s *userService) DeleteStaple(userID, stapleID uuid.UUID) error {
+	return s.userRepo.DeleteStaple(userID, stapleID)
 }
\ No newline at end of file
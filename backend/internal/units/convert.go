@@ -0,0 +1,111 @@
+package units
+
+import (
+	"math"
+	"strings"
+)
+
+// temperatureUnits maps temperature spellings to their system, mirroring the
+// temperature table of utils/measurements.ts.
+var temperatureUnits = map[string]System{
+	"c":          Metric,
+	"°c":         Metric,
+	"celsius":    Metric,
+	"f":          Imperial,
+	"°f":         Imperial,
+	"fahrenheit": Imperial,
+}
+
+// Convert expresses an amount in the given measurement system, picking the
+// most readable unit of that system. Temperatures are converted between
+// Celsius and Fahrenheit. The boolean is false when the unit is unknown, in
+// which case the amount and unit are returned unchanged.
+func Convert(amount float64, unit string, system System) (float64, string, bool) {
+	if from, ok := temperatureUnits[strings.ToLower(strings.TrimSpace(unit))]; ok {
+		value, converted := ConvertTemperature(amount, from, system)
+		return value, converted, true
+	}
+
+	u, ok := Lookup(unit)
+	if !ok {
+		return amount, unit, false
+	}
+
+	best := bestUnit(amount*u.Factor, u.Kind, system)
+	value := amount * u.Factor / best.Factor
+	return Round(value, best.Name), best.Name, true
+}
+
+// ConvertTemperature converts a temperature from one system's scale to the
+// other's, rounded to one decimal.
+func ConvertTemperature(value float64, from, to System) (float64, string) {
+	celsius := value
+	if from == Imperial {
+		celsius = (value - 32) * 5 / 9
+	}
+
+	if to == Imperial {
+		return math.Round((celsius*9/5+32)*10) / 10, "°F"
+	}
+	return math.Round(celsius*10) / 10, "°C"
+}
+
+// ConvertIngredient converts an ingredient amount to the given system. When
+// converting to metric, volumes of ingredients with a known density (flour,
+// sugar, butter...) are turned into weights, as metric recipes weigh them.
+// Temperature units are not considered, since "c" on an ingredient line
+// usually means cups.
+func ConvertIngredient(amount float64, unit, name string, system System) (float64, string, bool) {
+	u, ok := Lookup(unit)
+	if !ok {
+		return amount, unit, false
+	}
+
+	if system == Metric {
+		if grams, ok := VolumeToWeight(amount, unit, name); ok {
+			best := bestUnit(grams, Weight, Metric)
+			return Round(grams/best.Factor, best.Name), best.Name, true
+		}
+	}
+
+	return Convert(amount, u.Name, system)
+}
+
+// Combine adds two amounts of the same ingredient and expresses the sum in
+// the system of the first unit. A volume and a weight can be combined when
+// the ingredient's density is known. Unknown units only combine with the
+// same unit. It returns false if the amounts cannot be combined.
+func Combine(a float64, aUnit string, b float64, bUnit string, name string) (float64, string, bool) {
+	au, aok := Lookup(aUnit)
+	bu, bok := Lookup(bUnit)
+	if !aok || !bok {
+		if aok || bok || Normalize(aUnit) != Normalize(bUnit) {
+			return 0, "", false
+		}
+		return Round(a+b, aUnit), aUnit, true
+	}
+
+	if au.Kind == bu.Kind {
+		base := a*au.Factor + b*bu.Factor
+		best := bestUnit(base, au.Kind, au.System)
+		return Round(base/best.Factor, best.Name), best.Name, true
+	}
+
+	aGrams, bGrams := a*au.Factor, b*bu.Factor
+	if au.Kind == Volume {
+		grams, ok := VolumeToWeight(a, aUnit, name)
+		if !ok {
+			return 0, "", false
+		}
+		aGrams = grams
+	} else {
+		grams, ok := VolumeToWeight(b, bUnit, name)
+		if !ok {
+			return 0, "", false
+		}
+		bGrams = grams
+	}
+
+	best := bestUnit(aGrams+bGrams, Weight, au.System)
+	return Round((aGrams+bGrams)/best.Factor, best.Name), best.Name, true
+}
@@ -0,0 +1,89 @@
+package units
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// densities holds grams per milliliter for ingredients that are commonly
+// measured by volume in imperial recipes and by weight in metric ones.
+var densities = map[string]float64{
+	"flour":             0.53,
+	"all-purpose flour": 0.53,
+	"bread flour":       0.54,
+	"whole wheat flour": 0.51,
+	"almond flour":      0.41,
+	"cornstarch":        0.54,
+	"sugar":             0.85,
+	"granulated sugar":  0.85,
+	"brown sugar":       0.93,
+	"powdered sugar":    0.51,
+	"icing sugar":       0.51,
+	"butter":            0.96,
+	"cocoa powder":      0.42,
+	"rolled oats":       0.38,
+	"oats":              0.38,
+	"rice":              0.85,
+	"honey":             1.42,
+	"maple syrup":       1.32,
+	"peanut butter":     1.08,
+	"chocolate chips":   0.72,
+	"breadcrumbs":       0.46,
+	"salt":              1.22,
+	"shredded cheese":   0.47,
+	"grated parmesan":   0.42,
+}
+
+// densityNames lists the keys of densities, longest first.
+var densityNames = func() []string {
+	names := make([]string, 0, len(densities))
+	for name := range densities {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}()
+
+// DensityOf returns the density in g/ml for an ingredient name. A known name
+// must match the last whole words of the ingredient, so "light brown sugar"
+// uses the brown sugar density rather than the plain sugar one, while
+// "buttermilk" and "rice vinegar" have no density at all.
+func DensityOf(name string) (float64, bool) {
+	ingredient := " " + strings.Join(densityWords(name), " ")
+	for _, known := range densityNames {
+		if strings.HasSuffix(ingredient, " "+known) {
+			return densities[known], true
+		}
+	}
+	return 0, false
+}
+
+// densityWords splits a name into lowercase words, keeping hyphenated words
+// such as "all-purpose" whole.
+func densityWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+}
+
+// VolumeToWeight converts a volume of an ingredient to grams using its
+// density. It returns false if the unit is not a volume or the density of
+// the ingredient is unknown.
+func VolumeToWeight(amount float64, unit, name string) (float64, bool) {
+	u, ok := Lookup(unit)
+	if !ok || u.Kind != Volume {
+		return 0, false
+	}
+
+	density, ok := DensityOf(name)
+	if !ok {
+		return 0, false
+	}
+	return amount * u.Factor * density, true
+}
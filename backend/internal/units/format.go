@@ -0,0 +1,36 @@
+package units
+
+import (
+	"math"
+	"strconv"
+)
+
+// fractionNames spells the kitchen fractions as cooks write them.
+var fractionNames = map[float64]string{
+	1.0 / 8: "1/8",
+	1.0 / 4: "1/4",
+	1.0 / 3: "1/3",
+	3.0 / 8: "3/8",
+	1.0 / 2: "1/2",
+	5.0 / 8: "5/8",
+	2.0 / 3: "2/3",
+	3.0 / 4: "3/4",
+	7.0 / 8: "7/8",
+}
+
+// FormatAmount writes an amount the way recipes do, using a mixed fraction
+// such as "1 1/2" when the amount is close to a kitchen fraction and up to
+// two decimals otherwise.
+func FormatAmount(amount float64) string {
+	whole := math.Floor(amount)
+	frac := amount - whole
+	for f, name := range fractionNames {
+		if math.Abs(frac-f) < 0.01 {
+			if whole == 0 {
+				return name
+			}
+			return strconv.FormatFloat(whole, 'f', -1, 64) + " " + name
+		}
+	}
+	return strconv.FormatFloat(math.Round(amount*100)/100, 'f', -1, 64)
+}
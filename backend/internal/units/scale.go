@@ -0,0 +1,101 @@
+package units
+
+import "math"
+
+// Scale multiplies an amount by factor, moves it to the most readable unit of
+// the same system and rounds it to a kitchen-friendly value. Amounts in
+// unknown or count-only units keep their unit and are only rounded.
+func Scale(amount float64, unit string, factor float64) (float64, string) {
+	return Promote(amount*factor, unit)
+}
+
+// Promote expresses an amount in the most readable unit of its own system,
+// e.g. 48 tsp becomes 1 cup and 1000 g becomes 1 kg.
+func Promote(amount float64, unit string) (float64, string) {
+	u, ok := Lookup(unit)
+	if !ok {
+		return Round(amount, unit), unit
+	}
+
+	best := bestUnit(amount*u.Factor, u.Kind, u.System)
+	value := amount * u.Factor / best.Factor
+	return Round(value, best.Name), best.Name
+}
+
+// bestUnit picks the unit a cook would use for a base amount (ml or g).
+func bestUnit(base float64, kind Kind, system System) Unit {
+	var name string
+	switch {
+	case kind == Volume && system == Metric:
+		name = "ml"
+		if base >= unitTable["l"].Factor {
+			name = "l"
+		}
+	case kind == Volume:
+		switch {
+		case base < unitTable["tbsp"].Factor:
+			name = "tsp"
+		case base < unitTable["cup"].Factor/4:
+			name = "tbsp"
+		case base < unitTable["gallon"].Factor:
+			name = "cup"
+		default:
+			name = "gallon"
+		}
+	case kind == Weight && system == Metric:
+		name = "g"
+		if base >= unitTable["kg"].Factor {
+			name = "kg"
+		}
+	default:
+		name = "oz"
+		if base >= unitTable["lb"].Factor {
+			name = "lb"
+		}
+	}
+	return unitTable[name]
+}
+
+// kitchenFractions are the fractional parts recipes are usually written with.
+var kitchenFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 3.0 / 8, 1.0 / 2, 5.0 / 8, 2.0 / 3, 3.0 / 4, 7.0 / 8, 1}
+
+// Round rounds an amount to a value that can be measured in a kitchen:
+// common fractions for imperial and count units, whole or half grams and
+// milliliters for small metric amounts and two decimals for kg and l.
+// A positive amount is never rounded down to zero.
+func Round(amount float64, unit string) float64 {
+	if amount <= 0 {
+		return 0
+	}
+
+	u, ok := Lookup(unit)
+	if ok && u.System == Metric {
+		switch {
+		case u.Factor > 1:
+			return math.Max(math.Round(amount*20)/20, 0.05)
+		case amount < 10:
+			return math.Max(math.Round(amount*2)/2, 0.5)
+		case amount < 100:
+			return math.Round(amount)
+		default:
+			return math.Round(amount/5) * 5
+		}
+	}
+
+	if amount >= 10 {
+		return math.Round(amount*2) / 2
+	}
+
+	whole := math.Floor(amount)
+	frac := amount - whole
+	closest := kitchenFractions[0]
+	for _, f := range kitchenFractions[1:] {
+		if math.Abs(frac-f) < math.Abs(frac-closest) {
+			closest = f
+		}
+	}
+	if whole == 0 && closest == 0 {
+		closest = kitchenFractions[1]
+	}
+	return whole + closest
+}
@@ -0,0 +1,98 @@
+package units
+
+import (
+	"errors"
+	"strings"
+)
+
+type Kind string
+
+const (
+	Volume Kind = "volume"
+	Weight Kind = "weight"
+)
+
+type System string
+
+const (
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// ParseSystem validates a measurement system name as sent by clients.
+func ParseSystem(s string) (System, error) {
+	switch System(strings.ToLower(s)) {
+	case Metric:
+		return Metric, nil
+	case Imperial:
+		return Imperial, nil
+	}
+	return "", errors.New("system must be metric or imperial")
+}
+
+// Unit describes a measurement unit and its factor to the base unit of its
+// kind (milliliters for volume, grams for weight).
+type Unit struct {
+	Name   string
+	Kind   Kind
+	System System
+	Factor float64
+}
+
+var unitTable = map[string]Unit{
+	"ml":     {Name: "ml", Kind: Volume, System: Metric, Factor: 1},
+	"l":      {Name: "l", Kind: Volume, System: Metric, Factor: 1000},
+	"tsp":    {Name: "tsp", Kind: Volume, System: Imperial, Factor: 4.929},
+	"tbsp":   {Name: "tbsp", Kind: Volume, System: Imperial, Factor: 14.787},
+	"fl oz":  {Name: "fl oz", Kind: Volume, System: Imperial, Factor: 29.574},
+	"cup":    {Name: "cup", Kind: Volume, System: Imperial, Factor: 236.588},
+	"pint":   {Name: "pint", Kind: Volume, System: Imperial, Factor: 473.176},
+	"quart":  {Name: "quart", Kind: Volume, System: Imperial, Factor: 946.353},
+	"gallon": {Name: "gallon", Kind: Volume, System: Imperial, Factor: 3785.41},
+	"g":      {Name: "g", Kind: Weight, System: Metric, Factor: 1},
+	"kg":     {Name: "kg", Kind: Weight, System: Metric, Factor: 1000},
+	"oz":     {Name: "oz", Kind: Weight, System: Imperial, Factor: 28.3495},
+	"lb":     {Name: "lb", Kind: Weight, System: Imperial, Factor: 453.592},
+}
+
+var aliases = map[string]string{
+	"milliliter":   "ml",
+	"milliliters":  "ml",
+	"liter":        "l",
+	"liters":       "l",
+	"teaspoon":     "tsp",
+	"teaspoons":    "tsp",
+	"tablespoon":   "tbsp",
+	"tablespoons":  "tbsp",
+	"fluid ounce":  "fl oz",
+	"fluid ounces": "fl oz",
+	"cups":         "cup",
+	"pints":        "pint",
+	"quarts":       "quart",
+	"gallons":      "gallon",
+	"gram":         "g",
+	"grams":        "g",
+	"kilogram":     "kg",
+	"kilograms":    "kg",
+	"ounce":        "oz",
+	"ounces":       "oz",
+	"pound":        "lb",
+	"pounds":       "lb",
+	"lbs":          "lb",
+}
+
+// Normalize returns the canonical spelling of a unit, or the trimmed,
+// lower-cased input when the unit is not known.
+func Normalize(unit string) string {
+	u := strings.ToLower(strings.TrimSpace(unit))
+	if canonical, ok := aliases[u]; ok {
+		return canonical
+	}
+	return u
+}
+
+// Lookup returns the unit definition for any known spelling of a unit.
+func Lookup(unit string) (Unit, bool) {
+	u, ok := unitTable[Normalize(unit)]
+	return u, ok
+}
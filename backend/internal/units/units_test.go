@@ -0,0 +1,124 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		unit     string
+		factor   float64
+		want     float64
+		wantUnit string
+	}{
+		{1, "cup", 2, 2, "cup"},
+		{1, "cups", 0.5, 0.5, "cup"},
+		{16, "tbsp", 1, 1, "cup"},
+		{1, "tbsp", 0.5, 1.5, "tsp"},
+		{1, "tsp", 0.5, 0.5, "tsp"},
+		{500, "g", 3, 1.5, "kg"},
+		{1, "kg", 0.25, 250, "g"},
+		{12, "oz", 2, 1.5, "lb"},
+		{750, "ml", 2, 1.5, "l"},
+		{3, "eggs", 1.5, 4.5, "eggs"},
+		{1, "", 1.0 / 3, 1.0 / 3, ""},
+		{2, "clove", 0.1, 0.25, "clove"},
+	}
+
+	for _, tt := range tests {
+		got, unit := Scale(tt.amount, tt.unit, tt.factor)
+		if !near(got, tt.want) || unit != tt.wantUnit {
+			t.Errorf("Scale(%v, %q, %v) = %v %q, want %v %q", tt.amount, tt.unit, tt.factor, got, unit, tt.want, tt.wantUnit)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		amount float64
+		unit   string
+		want   float64
+	}{
+		{0, "g", 0},
+		{-1, "cup", 0},
+		{0.01, "cup", 0.125},
+		{1.3, "cup", 1 + 1.0/3},
+		{0.7, "", 2.0 / 3},
+		{2.95, "tsp", 3},
+		{10.3, "cup", 10.5},
+		{0.1, "g", 0.5},
+		{2.3, "g", 2.5},
+		{42.4, "ml", 42},
+		{123, "g", 125},
+		{1.234, "kg", 1.25},
+		{0.01, "l", 0.05},
+	}
+
+	for _, tt := range tests {
+		if got := Round(tt.amount, tt.unit); !near(got, tt.want) {
+			t.Errorf("Round(%v, %q) = %v, want %v", tt.amount, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		unit     string
+		system   System
+		want     float64
+		wantUnit string
+		ok       bool
+	}{
+		{1, "cup", Metric, 235, "ml", true},
+		{1, "tsp", Metric, 5, "ml", true},
+		{1, "gallon", Metric, 3.8, "l", true},
+		{500, "g", Imperial, 1.125, "lb", true},
+		{100, "g", Imperial, 3.5, "oz", true},
+		{250, "ml", Imperial, 1, "cup", true},
+		{1, "cup", Imperial, 1, "cup", true},
+		{350, "F", Metric, 176.7, "°C", true},
+		{180, "°C", Imperial, 356, "°F", true},
+		{200, "celsius", Metric, 200, "°C", true},
+		{2, "handful", Metric, 2, "handful", false},
+	}
+
+	for _, tt := range tests {
+		got, unit, ok := Convert(tt.amount, tt.unit, tt.system)
+		if !near(got, tt.want) || unit != tt.wantUnit || ok != tt.ok {
+			t.Errorf("Convert(%v, %q, %s) = %v %q %v, want %v %q %v", tt.amount, tt.unit, tt.system, got, unit, ok, tt.want, tt.wantUnit, tt.ok)
+		}
+	}
+}
+
+func TestDensityOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want float64
+		ok   bool
+	}{
+		{"butter", 0.96, true},
+		{"Unsalted Butter", 0.96, true},
+		{"light brown sugar", 0.93, true},
+		{"all-purpose flour", 0.53, true},
+		{"creamy peanut butter", 1.08, true},
+		{"long grain rice", 0.85, true},
+		{"buttermilk", 0, false},
+		{"rice vinegar", 0, false},
+		{"rice wine", 0, false},
+		{"sugar snap peas", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := DensityOf(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("DensityOf(%q) = %v %v, want %v %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}